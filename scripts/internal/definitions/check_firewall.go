@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runCheckFirewall(args []string) error {
+	flags, repoRoot := newFlagSet("check-firewall")
+	ref := flags.String("ref", "", "check the rules at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	rules, err := firewall.Load(src)
+	if err != nil {
+		return err
+	}
+
+	failures, warnings := firewall.Lint(rules)
+	for _, warning := range warnings {
+		fmt.Println("warning:", warning)
+	}
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("check-firewall: %d failure(s)", len(failures))
+	}
+	count := 0
+	for _, policy := range firewall.Policies {
+		count += len(rules.Rules(policy))
+	}
+	fmt.Printf("%d firewall rules are valid\n", count)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+func runCheckMembership(args []string) error {
+	flags, repoRoot := newFlagSet("check-membership")
+	ref := flags.String("ref", "", "check the definitions at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+
+	applications, err := environments.Load(src)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+
+	failures := networks.CheckMembership(applications, loaded)
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("check-membership: %d failure(s)", len(failures))
+	}
+	fmt.Println("every environment is in the expected network")
+	return nil
+}
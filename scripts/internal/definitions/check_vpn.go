@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+	"modernisation-platform/definitions/vpn"
+)
+
+func runCheckVPN(args []string) error {
+	flags, repoRoot := newFlagSet("check-vpn")
+	ref := flags.String("ref", "", "check the VPN attachments at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := vpn.Load(src)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+	tgwAsns, err := vpn.LoadTransitGatewayAsns(src)
+	if err != nil {
+		return err
+	}
+
+	if err := writeVPNSummary(os.Stdout, attachments, loaded); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	failures, warnings := vpn.Check(attachments, loaded, tgwAsns)
+	for _, warning := range warnings {
+		fmt.Println("warning:", warning)
+	}
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("check-vpn: %d failure(s)", len(failures))
+	}
+	fmt.Printf("%d VPN attachments are valid\n", len(attachments))
+	return nil
+}
+
+// writeVPNSummary writes a row per VPN with its peer, routing, tunnels and remote network
+func writeVPNSummary(w io.Writer, attachments []vpn.Attachment, defined []networks.Network) error {
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VPN\tCUSTOMER GATEWAY\tASN\tROUTING\tTUNNEL INSIDE CIDRS\tREMOTE NETWORK")
+	for _, a := range attachments {
+		tunnels := []string{}
+		for _, tunnel := range []string{a.Tunnel1InsideCidr, a.Tunnel2InsideCidr} {
+			if tunnel == "" {
+				tunnel = "-"
+			}
+			tunnels = append(tunnels, tunnel)
+		}
+		remote, source := a.Remote(defined)
+		switch {
+		case remote == "":
+			remote = "unknown"
+		case source != "remote_ipv4_network_cidr":
+			remote = fmt.Sprintf("%s (%s)", remote, source)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", a.Name, a.CustomerGatewayIP, a.BgpAsn, a.Routing(), strings.Join(tunnels, ","), remote)
+	}
+	return writer.Flush()
+}
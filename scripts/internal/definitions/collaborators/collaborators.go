@@ -0,0 +1,62 @@
+// Package collaborators models collaborators.json, which grants individual
+// users access to member accounts.
+package collaborators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"modernisation-platform/definitions/jsonfmt"
+	"modernisation-platform/definitions/repo"
+)
+
+// File is the location of the collaborators relative to the repository root
+const File = "collaborators.json"
+
+type Collaborators struct {
+	Users []User `json:"users"`
+}
+
+type User struct {
+	Username       string    `json:"username"`
+	GithubUsername string    `json:"github-username"`
+	Accounts       []Account `json:"accounts"`
+}
+
+type Account struct {
+	AccountName string `json:"account-name"`
+	Access      string `json:"access"`
+}
+
+// Load reads the collaborators from src. A missing file is treated as having no collaborators.
+func Load(src repo.Source) (Collaborators, error) {
+	var collaborators Collaborators
+
+	jsonData, err := src.ReadFile(File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return collaborators, nil
+	}
+	if err != nil {
+		return collaborators, err
+	}
+
+	if err := json.Unmarshal(jsonData, &collaborators); err != nil {
+		return collaborators, fmt.Errorf("%s: %w", File, err)
+	}
+	return collaborators, nil
+}
+
+// Schema is the canonical key order of collaborators.json
+var Schema = &jsonfmt.Schema{
+	Keys: []string{"users"},
+	Fields: map[string]*jsonfmt.Schema{
+		"users": {
+			Keys: []string{"username", "github-username", "accounts"},
+			Fields: map[string]*jsonfmt.Schema{
+				"accounts": {Keys: []string{"account-name", "access"}},
+			},
+		},
+	},
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"modernisation-platform/definitions/estate"
+	"modernisation-platform/definitions/repo"
+)
+
+func runDiff(args []string) error {
+	flags, repoRoot := newFlagSet("diff")
+	from := flags.String("from", "", "git ref to compare from, e.g. a tag or main@{3.months.ago}")
+	to := flags.String("to", "", "git ref to compare to, defaults to the working tree")
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+
+	if *from == "" {
+		return errors.New("diff: --from is required")
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+
+	fromSrc, err := repo.Open(root, *from)
+	if err != nil {
+		return err
+	}
+	toSrc, err := repo.Open(root, *to)
+	if err != nil {
+		return err
+	}
+	before, err := estate.Load(fromSrc)
+	if err != nil {
+		return err
+	}
+	after, err := estate.Load(toSrc)
+	if err != nil {
+		return err
+	}
+
+	changelog := estate.Compare(before, after)
+	switch *format {
+	case "text":
+		changelog.WriteText(os.Stdout)
+		return nil
+	case "json":
+		return changelog.WriteJSON(os.Stdout)
+	default:
+		return fmt.Errorf("diff: unknown format %q, expected text or json", *format)
+	}
+}
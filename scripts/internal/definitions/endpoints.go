@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+func runEndpoints(args []string) error {
+	flags, repoRoot := newFlagSet("endpoints")
+	format := flags.String("format", "markdown", "output format: markdown or csv")
+	driftOnly := flags.Bool("drift-only", false, "only show endpoints that differ between production and the lower tiers")
+	ref := flags.String("ref", "", "read the definitions at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+
+	matrix, tiers := networks.EndpointMatrix(loaded)
+	if *driftOnly {
+		drifted := []networks.EndpointUsage{}
+		for _, row := range matrix {
+			if row.Drift != "" {
+				drifted = append(drifted, row)
+			}
+		}
+		matrix = drifted
+	}
+
+	switch *format {
+	case "markdown":
+		return writeEndpointsMarkdown(os.Stdout, matrix, tiers)
+	case "csv":
+		return writeEndpointsCSV(os.Stdout, matrix, tiers)
+	default:
+		return fmt.Errorf("endpoints: unknown format %q, expected markdown or csv", *format)
+	}
+}
+
+// endpointRecords returns the header and a row per endpoint, with x for each tier using it
+func endpointRecords(matrix []networks.EndpointUsage, tiers []string) [][]string {
+	records := [][]string{append(append([]string{"business unit", "endpoint"}, tiers...), "drift")}
+	for _, row := range matrix {
+		record := []string{row.BusinessUnit, row.Endpoint}
+		for _, tier := range tiers {
+			cell := ""
+			if row.Tiers[tier] {
+				cell = "x"
+			}
+			record = append(record, cell)
+		}
+		records = append(records, append(record, row.Drift))
+	}
+	return records
+}
+
+func writeEndpointsCSV(w io.Writer, matrix []networks.EndpointUsage, tiers []string) error {
+	writer := csv.NewWriter(w)
+	writer.WriteAll(endpointRecords(matrix, tiers))
+	return writer.Error()
+}
+
+func writeEndpointsMarkdown(w io.Writer, matrix []networks.EndpointUsage, tiers []string) error {
+	var b strings.Builder
+	records := endpointRecords(matrix, tiers)
+	for i, record := range records {
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(record, " | "))
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", len(record)) + "|\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,155 @@
+// Package environments models the application definitions in environments/*.json.
+package environments
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/jsonfmt"
+	"modernisation-platform/definitions/repo"
+)
+
+// Dir is the location of the definitions relative to the repository root
+const Dir = "environments"
+
+// Definition is a single environments/<application>.json file. Fields are in
+// the order they are written to disk.
+type Definition struct {
+	AccountType                string        `json:"account-type"`
+	Components                 []Component   `json:"components,omitempty"`
+	Codeowners                 []string      `json:"codeowners,omitempty"`
+	IsolatedNetwork            string        `json:"isolated-network,omitempty"`
+	Environments               []Environment `json:"environments"`
+	Tags                       Tags          `json:"tags"`
+	GithubOidcTeamRepositories []string      `json:"github-oidc-team-repositories"`
+	GoLiveDate                 string        `json:"go-live-date"`
+}
+
+type Component struct {
+	Name         string `json:"name"`
+	SsoGroupName string `json:"sso_group_name,omitempty"`
+}
+
+type Environment struct {
+	Name                  string   `json:"name"`
+	Access                []Access `json:"access"`
+	AdditionalReviewers   []string `json:"additional_reviewers,omitempty"`
+	InstanceSchedulerSkip []string `json:"instance_scheduler_skip,omitempty"`
+	Nuke                  string   `json:"nuke,omitempty"`
+}
+
+type Access struct {
+	SsoGroupName         string `json:"sso_group_name"`
+	Level                string `json:"level"`
+	GithubActionReviewer string `json:"github_action_reviewer,omitempty"`
+	Nuke                 string `json:"nuke,omitempty"`
+}
+
+type Tags struct {
+	Application                    string `json:"application"`
+	BusinessUnit                   string `json:"business-unit"`
+	InfrastructureSupport          string `json:"infrastructure-support"`
+	Owner                          string `json:"owner"`
+	SlackChannel                   string `json:"slack-channel,omitempty"`
+	CriticalNationalInfrastructure bool   `json:"critical-national-infrastructure"`
+}
+
+// Application is a definition together with its name, taken from the file name
+type Application struct {
+	Name string
+	Definition
+}
+
+// Account is a single AWS account created from an application environment
+type Account struct {
+	Application string
+	Environment string
+}
+
+// Name is the account name used throughout the platform, e.g. nomis-development
+func (a Account) Name() string {
+	return a.Application + "-" + a.Environment
+}
+
+// Isolated reports whether the application opts out of the shared VPCs
+func (a Application) Isolated() bool {
+	return a.IsolatedNetwork == "true"
+}
+
+// Accounts expands the application into one account per environment
+func (a Application) Accounts() []Account {
+	accounts := make([]Account, 0, len(a.Environments))
+	for _, env := range a.Environments {
+		accounts = append(accounts, Account{Application: a.Name, Environment: env.Name})
+	}
+	return accounts
+}
+
+// Marshal encodes the definition as it is written to disk, laid out by
+// jsonfmt so a new definition already matches `fmt` and prettier
+func (d Definition) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return nil, err
+	}
+	return jsonfmt.Format(buf.Bytes(), Schema)
+}
+
+// Load reads every application definition from src, sorted by name
+func Load(src repo.Source) ([]Application, error) {
+	files, err := src.ListFiles(Dir, ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	applications := make([]Application, 0, len(files))
+	for _, file := range files {
+		jsonData, err := src.ReadFile(Dir + "/" + file)
+		if err != nil {
+			return nil, err
+		}
+		var definition Definition
+		if err := json.Unmarshal(jsonData, &definition); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", Dir, file, err)
+		}
+		applications = append(applications, Application{
+			Name:       strings.TrimSuffix(file, ".json"),
+			Definition: definition,
+		})
+	}
+
+	sort.Slice(applications, func(i, j int) bool { return applications[i].Name < applications[j].Name })
+	return applications, nil
+}
+
+// Schema is the canonical key order of a definition, matching the field order of Definition
+var Schema = &jsonfmt.Schema{
+	Keys: []string{"account-type", "components", "codeowners", "isolated-network", "environments", "tags", "github-oidc-team-repositories", "go-live-date"},
+	Fields: map[string]*jsonfmt.Schema{
+		"components": {Keys: []string{"name", "sso_group_name"}},
+		"environments": {
+			Keys: []string{"name", "access", "additional_reviewers", "instance_scheduler_skip", "nuke"},
+			Fields: map[string]*jsonfmt.Schema{
+				"access": {Keys: []string{"sso_group_name", "level", "github_action_reviewer", "nuke"}},
+			},
+		},
+		"tags": {Keys: []string{"application", "business-unit", "infrastructure-support", "owner", "slack-channel", "critical-national-infrastructure"}},
+	},
+}
+
+// AccountNames returns the name of every account defined by applications
+func AccountNames(applications []Application) map[string]bool {
+	names := map[string]bool{}
+	for _, app := range applications {
+		for _, account := range app.Accounts() {
+			names[account.Name()] = true
+		}
+	}
+	return names
+}
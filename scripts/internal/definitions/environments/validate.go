@@ -0,0 +1,144 @@
+package environments
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The allowed values below mirror policies/environments and policies/member,
+// which remain the source of truth in CI. Keep them in step when the policies change.
+
+var AllowedBusinessUnits = []string{
+	"HQ",
+	"HMPPS",
+	"OPG",
+	"LAA",
+	"HMCTS",
+	"CICA",
+	"Platforms",
+	"CJSE",
+}
+
+var AllowedAccess = []string{
+	"administrator",
+	"data-engineer",
+	"developer",
+	"instance-access",
+	"instance-management",
+	"migration",
+	"mwaa-user",
+	"read-only",
+	"reporting-operations",
+	"sandbox",
+	"security-audit",
+	"view-only",
+	"powerbi-user",
+	"fleet-manager",
+	"quicksight-admin",
+}
+
+var AllowedNuke = []string{
+	"include",
+	"exclude",
+	"rebuild",
+}
+
+// AllowedEnvironments only applies to member accounts
+var AllowedEnvironments = []string{
+	"development",
+	"test",
+	"preproduction",
+	"production",
+}
+
+var (
+	businessUnitPattern          = regexp.MustCompile(`^[a-zA-Z-]{1,20}$`)
+	emailPattern                 = regexp.MustCompile(`^\S+@\S+$`)
+	memberApplicationNamePattern = regexp.MustCompile(`^[a-z-]{1,30}$`)
+)
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks an application against the environment definition policies,
+// returning one message per failure in the same wording as the policies
+func Validate(app Application) []string {
+	filename := Dir + "/" + app.Name + ".json"
+	failures := []string{}
+	fail := func(format string, args ...any) {
+		failures = append(failures, fmt.Sprintf("`%v` "+format, append([]any{filename}, args...)...))
+	}
+
+	if len(app.Environments) == 0 {
+		fail("has no environments")
+	}
+	for _, env := range app.Environments {
+		if env.Name == "" {
+			fail("has an environment that is missing a `name` value")
+		}
+		if env.Access == nil {
+			fail("has an environment that is missing a `access` value")
+		}
+		for _, access := range env.Access {
+			if !contains(AllowedAccess, access.Level) {
+				fail("uses an unexpected access level: got `%v`, expected one of: %v", access.Level, strings.Join(AllowedAccess, ", "))
+			}
+			if access.Nuke != "" && !contains(AllowedNuke, access.Nuke) {
+				fail("uses an unexpected nuke value: got `%v`, expected one of: %v", access.Nuke, strings.Join(AllowedNuke, ", "))
+			}
+			if access.Level == "powerbi-user" && !strings.HasPrefix(app.Name, "analytical-platform") && !strings.HasPrefix(app.Name, "sprinkler") {
+				fail("uses `powerbi-user` access level but is not an analytical platform or sprinkler account")
+			}
+		}
+	}
+
+	if app.Tags.Application == "" {
+		fail("is missing the `application` tag")
+	}
+	if app.Tags.BusinessUnit == "" {
+		fail("is missing the `business-unit` tag")
+	}
+	if !contains(AllowedBusinessUnits, app.Tags.BusinessUnit) {
+		fail("uses an unexpected business-unit: got `%v`, expected one of: %v", app.Tags.BusinessUnit, strings.Join(AllowedBusinessUnits, ", "))
+	}
+	if !businessUnitPattern.MatchString(app.Tags.BusinessUnit) {
+		fail("Business unit name does not meet requirements")
+	}
+	if app.Tags.Owner == "" {
+		fail("is missing the `owner` tag")
+	}
+	if !emailPattern.MatchString(app.Tags.InfrastructureSupport) {
+		fail("infrastructure-support value is not a valid email address")
+	}
+	if app.GithubOidcTeamRepositories == nil {
+		fail("is missing the `github-oidc-team-repositories` key")
+	}
+
+	if app.AccountType == "member" {
+		for _, env := range app.Environments {
+			if !contains(AllowedEnvironments, env.Name) {
+				fail("uses an unexpected environment: got `%v`, expected one of: %v", env.Name, strings.Join(AllowedEnvironments, ", "))
+			}
+		}
+		if !memberApplicationNamePattern.MatchString(app.Name) {
+			fail("filename does not meet requirements")
+		}
+	}
+
+	// Not covered by the policies, but the summary and reporting scripts rely on it
+	if app.GoLiveDate != "" {
+		if _, err := time.Parse("2006-01-02", app.GoLiveDate); err != nil {
+			fail("has an invalid `go-live-date`: got `%v`, expected YYYY-MM-DD", app.GoLiveDate)
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,77 @@
+package environments
+
+import (
+	"strings"
+	"testing"
+)
+
+func validApplication() Application {
+	return Application{
+		Name: "example",
+		Definition: Definition{
+			AccountType: "member",
+			Environments: []Environment{
+				{Name: "development", Access: []Access{{SsoGroupName: "modernisation-platform", Level: "developer"}}},
+			},
+			Tags: Tags{
+				Application:           "example",
+				BusinessUnit:          "Platforms",
+				InfrastructureSupport: "modernisation-platform@digital.justice.gov.uk",
+				Owner:                 "Modernisation Platform: modernisation-platform@digital.justice.gov.uk",
+			},
+			GithubOidcTeamRepositories: []string{},
+		},
+	}
+}
+
+func TestValidateAcceptsValidApplication(t *testing.T) {
+	if failures := Validate(validApplication()); len(failures) != 0 {
+		t.Errorf("expected no failures, got %v", failures)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(app *Application)
+		expected string
+	}{
+		{"no environments", func(app *Application) { app.Environments = nil }, "`environments/example.json` has no environments"},
+		{"missing access", func(app *Application) { app.Environments[0].Access = nil }, "`environments/example.json` has an environment that is missing a `access` value"},
+		{"missing application tag", func(app *Application) { app.Tags.Application = "" }, "`environments/example.json` is missing the `application` tag"},
+		{"missing owner tag", func(app *Application) { app.Tags.Owner = "" }, "`environments/example.json` is missing the `owner` tag"},
+		{"unexpected business unit", func(app *Application) { app.Tags.BusinessUnit = "incorrect-business-unit" }, "`environments/example.json` uses an unexpected business-unit: got `incorrect-business-unit`, expected one of: HQ, HMPPS, OPG, LAA, HMCTS, CICA, Platforms, CJSE"},
+		{"business unit characters", func(app *Application) { app.Tags.BusinessUnit = "Platforms4" }, "`environments/example.json` Business unit name does not meet requirements"},
+		{"unexpected access", func(app *Application) { app.Environments[0].Access[0].Level = "incorrect-access" }, "`environments/example.json` uses an unexpected access level: got `incorrect-access`"},
+		{"powerbi access", func(app *Application) { app.Environments[0].Access[0].Level = "powerbi-user" }, "`environments/example.json` uses `powerbi-user` access level but is not an analytical platform or sprinkler account"},
+		{"unexpected nuke", func(app *Application) { app.Environments[0].Access[0].Nuke = "incorrect-value" }, "`environments/example.json` uses an unexpected nuke value: got `incorrect-value`, expected one of: include, exclude, rebuild"},
+		{"invalid email", func(app *Application) { app.Tags.InfrastructureSupport = "not-a-valid-email-address" }, "`environments/example.json` infrastructure-support value is not a valid email address"},
+		{"missing oidc repositories", func(app *Application) { app.GithubOidcTeamRepositories = nil }, "`environments/example.json` is missing the `github-oidc-team-repositories` key"},
+		{"unexpected member environment", func(app *Application) { app.Environments[0].Name = "staging" }, "`environments/example.json` uses an unexpected environment: got `staging`, expected one of: development, test, preproduction, production"},
+		{"member filename", func(app *Application) { app.Name = "Example_App" }, "`environments/Example_App.json` filename does not meet requirements"},
+		{"invalid go-live date", func(app *Application) { app.GoLiveDate = "01-02-2025" }, "`environments/example.json` has an invalid `go-live-date`: got `01-02-2025`, expected YYYY-MM-DD"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := validApplication()
+			test.modify(&app)
+			failures := Validate(app)
+			for _, failure := range failures {
+				if strings.HasPrefix(failure, test.expected) {
+					return
+				}
+			}
+			t.Errorf("expected a failure starting %q, got %v", test.expected, failures)
+		})
+	}
+}
+
+func TestValidateMemberRulesOnlyApplyToMembers(t *testing.T) {
+	app := validApplication()
+	app.AccountType = "core"
+	app.Environments[0].Name = "non-production"
+	if failures := Validate(app); len(failures) != 0 {
+		t.Errorf("expected no failures for a core account, got %v", failures)
+	}
+}
@@ -0,0 +1,215 @@
+// Package estate compares the platform's definitions at two points in time.
+package estate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"modernisation-platform/definitions/collaborators"
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// Snapshot is everything the platform defines at one revision
+type Snapshot struct {
+	Source        string
+	Applications  []environments.Application
+	Networks      []networks.Network
+	Collaborators collaborators.Collaborators
+}
+
+// Load reads a snapshot from src
+func Load(src repo.Source) (Snapshot, error) {
+	snapshot := Snapshot{Source: src.String()}
+
+	var err error
+	if snapshot.Applications, err = environments.Load(src); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Networks, err = networks.Load(src); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Collaborators, err = collaborators.Load(src); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// section turns a snapshot into a set of comparable items. Anything that can
+// change in place (e.g. a subnet set CIDR) is part of the item, so a change
+// shows up as one item removed and another added.
+type section struct {
+	name  string
+	items func(s Snapshot) []string
+}
+
+var sections = []section{
+	{"Applications", func(s Snapshot) []string {
+		items := []string{}
+		for _, app := range s.Applications {
+			items = append(items, app.Name)
+		}
+		return items
+	}},
+	{"Environments", func(s Snapshot) []string {
+		items := []string{}
+		for _, app := range s.Applications {
+			for _, account := range app.Accounts() {
+				items = append(items, account.Name())
+			}
+		}
+		return items
+	}},
+	{"Access grants", func(s Snapshot) []string {
+		items := []string{}
+		for _, app := range s.Applications {
+			for _, env := range app.Environments {
+				for _, access := range env.Access {
+					items = append(items, fmt.Sprintf("%s-%s: %s as %s", app.Name, env.Name, access.SsoGroupName, access.Level))
+				}
+			}
+		}
+		return items
+	}},
+	{"Subnet sets", func(s Snapshot) []string {
+		items := []string{}
+		for _, network := range s.Networks {
+			for _, name := range network.SubnetSetNames() {
+				items = append(items, fmt.Sprintf("%s/%s (%s)", network.Name, name, network.Cidr.SubnetSets[name].Cidr))
+			}
+		}
+		return items
+	}},
+	{"Subnet set accounts", func(s Snapshot) []string {
+		items := []string{}
+		for _, network := range s.Networks {
+			for _, name := range network.SubnetSetNames() {
+				for _, account := range network.Cidr.SubnetSets[name].Accounts {
+					items = append(items, fmt.Sprintf("%s/%s: %s", network.Name, name, account))
+				}
+			}
+		}
+		return items
+	}},
+	{"Endpoints", func(s Snapshot) []string {
+		items := []string{}
+		for _, network := range s.Networks {
+			for _, endpoint := range network.Options.AdditionalEndpoints {
+				items = append(items, fmt.Sprintf("%s: %s", network.Name, endpoint))
+			}
+		}
+		return items
+	}},
+	{"Collaborators", func(s Snapshot) []string {
+		items := []string{}
+		for _, user := range s.Collaborators.Users {
+			items = append(items, user.Username)
+		}
+		return items
+	}},
+	{"Collaborator access", func(s Snapshot) []string {
+		items := []string{}
+		for _, user := range s.Collaborators.Users {
+			for _, account := range user.Accounts {
+				items = append(items, fmt.Sprintf("%s: %s as %s", user.Username, account.AccountName, account.Access))
+			}
+		}
+		return items
+	}},
+}
+
+// Change lists what was added to and removed from one section
+type Change struct {
+	Section string   `json:"section"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Changelog is the difference between two snapshots
+type Changelog struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Changes []Change `json:"changes"`
+}
+
+// Compare returns what changed between from and to, with one entry per section
+func Compare(from, to Snapshot) Changelog {
+	changelog := Changelog{From: from.Source, To: to.Source, Changes: []Change{}}
+	for _, section := range sections {
+		added, removed := difference(section.items(from), section.items(to))
+		changelog.Changes = append(changelog.Changes, Change{
+			Section: section.name,
+			Added:   added,
+			Removed: removed,
+		})
+	}
+	return changelog
+}
+
+// difference returns the items only in to (added) and only in from (removed), sorted
+func difference(from, to []string) (added, removed []string) {
+	inFrom := map[string]bool{}
+	for _, item := range from {
+		inFrom[item] = true
+	}
+	inTo := map[string]bool{}
+	for _, item := range to {
+		inTo[item] = true
+	}
+
+	added, removed = []string{}, []string{}
+	for item := range inTo {
+		if !inFrom[item] {
+			added = append(added, item)
+		}
+	}
+	for item := range inFrom {
+		if !inTo[item] {
+			removed = append(removed, item)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// Empty reports whether nothing changed
+func (c Changelog) Empty() bool {
+	for _, change := range c.Changes {
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText writes the changelog in a readable form, leaving out unchanged sections
+func (c Changelog) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Changes from %s to %s\n", c.From, c.To)
+	if c.Empty() {
+		fmt.Fprintln(w, "\nNo changes")
+		return
+	}
+	for _, change := range c.Changes {
+		if len(change.Added) == 0 && len(change.Removed) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (+%d -%d):\n", change.Section, len(change.Added), len(change.Removed))
+		for _, item := range change.Added {
+			fmt.Fprintf(w, "  + %s\n", item)
+		}
+		for _, item := range change.Removed {
+			fmt.Fprintf(w, "  - %s\n", item)
+		}
+	}
+}
+
+// WriteJSON writes the full changelog, including unchanged sections
+func (c Changelog) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
@@ -0,0 +1,100 @@
+package estate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+var before = repotest.Memory{
+	"environments/alpha.json": `{
+		"account-type": "member",
+		"environments": [
+			{"name": "development", "access": [{"sso_group_name": "alpha-team", "level": "developer"}]}
+		],
+		"tags": {"application": "alpha", "business-unit": "HMPPS"}
+	}`,
+	"environments/bravo.json": `{
+		"account-type": "member",
+		"environments": [{"name": "production", "access": []}],
+		"tags": {"application": "bravo", "business-unit": "LAA"}
+	}`,
+	"environments-networks/hmpps-development.json": `{
+		"cidr": {"subnet_sets": {"general": {"cidr": "10.26.24.0/21", "accounts": ["alpha-development"]}}},
+		"options": {"additional_endpoints": ["com.amazonaws.eu-west-2.athena"]}
+	}`,
+	"collaborators.json": `{"users": [
+		{"username": "jane", "accounts": [{"account-name": "alpha-development", "access": "developer"}]}
+	]}`,
+}
+
+var after = repotest.Memory{
+	"environments/alpha.json": `{
+		"account-type": "member",
+		"environments": [
+			{"name": "development", "access": [{"sso_group_name": "alpha-team", "level": "sandbox"}]},
+			{"name": "production", "access": []}
+		],
+		"tags": {"application": "alpha", "business-unit": "HMPPS"}
+	}`,
+	"environments/charlie.json": `{
+		"account-type": "member",
+		"environments": [{"name": "test", "access": []}],
+		"tags": {"application": "charlie", "business-unit": "OPG"}
+	}`,
+	"environments-networks/hmpps-development.json": `{
+		"cidr": {"subnet_sets": {"general": {"cidr": "10.26.32.0/21", "accounts": ["alpha-development"]}}},
+		"options": {"additional_endpoints": ["com.amazonaws.eu-west-2.athena", "com.amazonaws.eu-west-2.glue"]}
+	}`,
+}
+
+func TestCompare(t *testing.T) {
+	from, err := Load(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := Load(after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2][]string{
+		"Applications":        {{"charlie"}, {"bravo"}},
+		"Environments":        {{"alpha-production", "charlie-test"}, {"bravo-production"}},
+		"Access grants":       {{"alpha-development: alpha-team as sandbox"}, {"alpha-development: alpha-team as developer"}},
+		"Subnet sets":         {{"hmpps-development/general (10.26.32.0/21)"}, {"hmpps-development/general (10.26.24.0/21)"}},
+		"Subnet set accounts": {{}, {}},
+		"Endpoints":           {{"hmpps-development: com.amazonaws.eu-west-2.glue"}, {}},
+		"Collaborators":       {{}, {"jane"}},
+		"Collaborator access": {{}, {"jane: alpha-development as developer"}},
+	}
+
+	changelog := Compare(from, to)
+	if len(changelog.Changes) != len(expected) {
+		t.Fatalf("got %d sections, expected %d", len(changelog.Changes), len(expected))
+	}
+	for _, change := range changelog.Changes {
+		want := expected[change.Section]
+		if strings.Join(change.Added, "|") != strings.Join(want[0], "|") {
+			t.Errorf("%s: got added %v, expected %v", change.Section, change.Added, want[0])
+		}
+		if strings.Join(change.Removed, "|") != strings.Join(want[1], "|") {
+			t.Errorf("%s: got removed %v, expected %v", change.Section, change.Removed, want[1])
+		}
+	}
+}
+
+func TestWriteTextSkipsUnchangedSections(t *testing.T) {
+	snapshot, err := Load(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	Compare(snapshot, snapshot).WriteText(&out)
+	if !strings.Contains(out.String(), "No changes") {
+		t.Errorf("expected no changes, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,200 @@
+// Package firewall models the network firewall rules in
+// terraform/environments/core-network-services/firewall-rules, resolving their
+// references the same way the stack's locals.tf does.
+package firewall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// Dir is the location of the rule files relative to the repository root
+const Dir = "terraform/environments/core-network-services/firewall-rules"
+
+// CidrRangesFile defines the ${name} ranges the rule files are templated with
+const CidrRangesFile = "terraform/environments/core-network-services/cidr-ranges.tf"
+
+// Policy is a firewall policy built from one or more rule files
+type Policy struct {
+	Name string
+	// Files are merged into one map of rules, later files replacing earlier ones
+	Files []string
+	// Sets is whether the policy has the IP_SETS and PORT_SETS from sets.json
+	Sets bool
+}
+
+// Policies are the firewall policies in core-network-services: the external
+// firewall's policy, from module.firewall_policy in firewall.tf, and the
+// inline inspection firewalls' policy in the vpc-inspection module
+var Policies = []Policy{
+	{
+		Name: "external",
+		Files: []string{
+			"development_rules.json",
+			"test_rules.json",
+			"preproduction_rules.json",
+			"production_rules.json",
+			"live_data_rules.json",
+			"non_live_data_rules.json",
+		},
+		Sets: true,
+	},
+	{Name: "inline", Files: []string{"inline_rules.json"}},
+}
+
+// Rule is one entry in a rule file
+type Rule struct {
+	Name            string `json:"-"`
+	File            string `json:"-"`
+	Action          string `json:"action"`
+	SourceIP        string `json:"source_ip"`
+	DestinationIP   string `json:"destination_ip"`
+	DestinationPort string `json:"destination_port"`
+	Protocol        string `json:"protocol"`
+}
+
+func (r Rule) String() string {
+	return r.File + " " + r.Name
+}
+
+// Sets are the rule variables in sets.json, referenced as $NAME from rules
+type Sets struct {
+	IPSets   map[string][]string `json:"IP_SETS"`
+	PortSets map[string][]string `json:"PORT_SETS"`
+}
+
+// FQDN is the domain allow list in fqdn_rules.json
+type FQDN struct {
+	AllowedDomains []string `json:"fw_allowed_domains"`
+	HomeNetIPs     []string `json:"fw_home_net_ips"`
+}
+
+// RuleSet is every rule file, templated with the CIDR ranges
+type RuleSet struct {
+	// Ranges are the ${name} CIDRs from cidr-ranges.tf and the general subnet sets
+	Ranges map[string]string
+	Sets   Sets
+	// Files are the rules in each rule file, sorted by name
+	Files map[string][]Rule
+	FQDN  FQDN
+	// Undefined are the ${name} references in each file that aren't in Ranges
+	Undefined map[string][]string
+}
+
+var (
+	// cidrRangeEntry matches e.g. `    psn = "51.0.0.0/8" # comment`
+	cidrRangeEntry    = regexp.MustCompile(`(?m)^\s*([A-Za-z0-9_-]+)\s*=\s*"([0-9.]+/[0-9]+)"`)
+	templateReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// LoadRanges returns the ranges available to the rule files as ${name}: the
+// ranges in cidr-ranges.tf, and each network's general subnet set by network name
+func LoadRanges(src repo.Source) (map[string]string, error) {
+	content, err := src.ReadFile(CidrRangesFile)
+	if err != nil {
+		return nil, err
+	}
+	ranges := map[string]string{}
+	for _, match := range cidrRangeEntry.FindAllStringSubmatch(string(content), -1) {
+		ranges[match[1]] = match[2]
+	}
+
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, network := range loaded {
+		if general, ok := network.Cidr.SubnetSets["general"]; ok {
+			ranges[network.Name] = general.Cidr
+		}
+	}
+	return ranges, nil
+}
+
+// Load reads and templates sets.json, fqdn_rules.json and every policy's rule files
+func Load(src repo.Source) (RuleSet, error) {
+	ranges, err := LoadRanges(src)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	rs := RuleSet{Ranges: ranges, Files: map[string][]Rule{}, Undefined: map[string][]string{}}
+
+	if err := rs.decode(src, "sets.json", &rs.Sets); err != nil {
+		return RuleSet{}, err
+	}
+	if err := rs.decode(src, "fqdn_rules.json", &rs.FQDN); err != nil {
+		return RuleSet{}, err
+	}
+	for _, policy := range Policies {
+		for _, file := range policy.Files {
+			var rules map[string]Rule
+			if err := rs.decode(src, file, &rules); err != nil {
+				return RuleSet{}, err
+			}
+			loaded := make([]Rule, 0, len(rules))
+			for name, rule := range rules {
+				rule.Name, rule.File = name, file
+				loaded = append(loaded, rule)
+			}
+			sort.Slice(loaded, func(i, j int) bool { return loaded[i].Name < loaded[j].Name })
+			rs.Files[file] = loaded
+		}
+	}
+	return rs, nil
+}
+
+// decode reads a file in Dir, replacing ${name} references as templatefile
+// does. Like locals.tf, a missing file is treated as empty.
+func (rs *RuleSet) decode(src repo.Source, file string, v any) error {
+	content, err := src.ReadFile(Dir + "/" + file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	templated := templateReference.ReplaceAllStringFunc(string(content), func(reference string) string {
+		name := templateReference.FindStringSubmatch(reference)[1]
+		if cidr, ok := rs.Ranges[name]; ok {
+			return cidr
+		}
+		rs.Undefined[file] = append(rs.Undefined[file], name)
+		return reference
+	})
+	if err := json.Unmarshal([]byte(templated), v); err != nil {
+		return fmt.Errorf("%s/%s: %w", Dir, file, err)
+	}
+	return nil
+}
+
+// Rules returns a policy's rules in the order Terraform gives them sids:
+// its files are merged into one map, which is iterated in key order
+func (rs RuleSet) Rules(policy Policy) []Rule {
+	merged := map[string]Rule{}
+	for _, file := range policy.Files {
+		for _, rule := range rs.Files[file] {
+			merged[rule.Name] = rule
+		}
+	}
+	rules := make([]Rule, 0, len(merged))
+	for _, rule := range merged {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// PolicySets returns the sets a policy's rules can reference
+func (rs RuleSet) PolicySets(policy Policy) Sets {
+	if !policy.Sets {
+		return Sets{}
+	}
+	return rs.Sets
+}
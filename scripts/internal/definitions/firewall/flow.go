@@ -0,0 +1,182 @@
+package firewall
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Flow is a connection from Source to Destination on Port
+type Flow struct {
+	Source      netip.Addr
+	Destination netip.Addr
+	// Port is ignored for ICMP
+	Port     int
+	Protocol string
+}
+
+func (f Flow) String() string {
+	if strings.EqualFold(f.Protocol, "ICMP") {
+		return fmt.Sprintf("%s -> %s ICMP", f.Source, f.Destination)
+	}
+	return fmt.Sprintf("%s -> %s:%d %s", f.Source, f.Destination, f.Port, strings.ToUpper(f.Protocol))
+}
+
+// Matches reports whether the rule's header matches the flow. IP rules match
+// every protocol. Every rule has direction ANY and source port ANY, so a rule
+// for any port also matches flows from its destination back to its source.
+func (r Resolved) Matches(flow Flow) bool {
+	if r.Protocol != "IP" && !strings.EqualFold(r.Protocol, flow.Protocol) {
+		return false
+	}
+	anyPort := slices.Contains(r.Ports, AnyPort)
+	portMatches := anyPort || strings.EqualFold(flow.Protocol, "ICMP") ||
+		slices.ContainsFunc(r.Ports, func(p PortRange) bool { return p.Contains(flow.Port) })
+
+	forward := containsAddr(r.Sources, flow.Source) && containsAddr(r.Destinations, flow.Destination)
+	reverse := containsAddr(r.Destinations, flow.Source) && containsAddr(r.Sources, flow.Destination)
+	return (forward && portMatches) || (reverse && anyPort)
+}
+
+// Covers reports whether every flow other's header matches is also matched by r's
+func (r Resolved) Covers(other Resolved) bool {
+	return (r.Protocol == "IP" || r.Protocol == other.Protocol) &&
+		prefixesWithin(other.Sources, r.Sources) &&
+		prefixesWithin(other.Destinations, r.Destinations) &&
+		portsWithin(other.Ports, r.Ports)
+}
+
+func prefixesWithin(inner, outer []netip.Prefix) bool {
+	for _, i := range inner {
+		if !slices.ContainsFunc(outer, func(o netip.Prefix) bool { return o.Bits() <= i.Bits() && o.Contains(i.Addr()) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func portsWithin(inner, outer []PortRange) bool {
+	for _, i := range inner {
+		if !slices.ContainsFunc(outer, func(o PortRange) bool { return o.From <= i.From && i.To <= o.To }) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// actionOrder is DEFAULT_ACTION_ORDER, which both policies use: every pass
+// rule is evaluated before any drop rule, whatever their sids
+var actionOrder = []string{"PASS", "DROP", "REJECT"}
+
+// DefaultAction is what happens to a flow no rule matches. Neither policy sets
+// stateful default actions, so it is passed, other than the HTTP and TLS
+// traffic the FQDN allow list drops.
+const DefaultAction = "PASS"
+
+// Verdict is the result of evaluating a flow against a policy
+type Verdict struct {
+	Action string
+	// Rule is the rule that decided the action, or nil for the default action
+	Rule *Resolved
+	// Alerts are the ALERT rules that also match, which log but don't decide the action
+	Alerts []Resolved
+}
+
+// Evaluate returns what a firewall with rules, in sid order, does with flow
+func Evaluate(rules []Resolved, flow Flow) Verdict {
+	verdict := Verdict{Action: DefaultAction}
+	for _, rule := range rules {
+		if rule.Action == "ALERT" && rule.Matches(flow) {
+			verdict.Alerts = append(verdict.Alerts, rule)
+		}
+	}
+	for _, action := range actionOrder {
+		for _, rule := range rules {
+			if rule.Action == action && rule.Matches(flow) {
+				verdict.Action = action
+				verdict.Rule = &rule
+				return verdict
+			}
+		}
+	}
+	return verdict
+}
+
+// Contexts are the names a set of rules can be evaluated by: a policy, or one
+// of the files merged into the external policy
+func Contexts() []string {
+	contexts := []string{}
+	for _, policy := range Policies {
+		contexts = append(contexts, policy.Name)
+	}
+	for _, policy := range Policies {
+		if len(policy.Files) > 1 {
+			for _, file := range policy.Files {
+				contexts = append(contexts, strings.TrimSuffix(file, "_rules.json"))
+			}
+		}
+	}
+	return contexts
+}
+
+// Context returns the resolved rules for a policy, e.g. external, or for one
+// rule file in it, e.g. live_data for live_data_rules.json, in sid order
+func (rs RuleSet) Context(name string) ([]Resolved, error) {
+	for _, policy := range Policies {
+		if policy.Name == name {
+			return rs.resolve(policy, rs.Rules(policy))
+		}
+	}
+	for _, policy := range Policies {
+		file := name + "_rules.json"
+		if slices.Contains(policy.Files, file) {
+			rules := []Rule{}
+			for _, rule := range rs.Rules(policy) {
+				if rule.File == file {
+					rules = append(rules, rule)
+				}
+			}
+			return rs.resolve(policy, rules)
+		}
+	}
+	return nil, fmt.Errorf("unknown firewall context %q, expected one of %s", name, strings.Join(Contexts(), ", "))
+}
+
+func (rs RuleSet) resolve(policy Policy, rules []Rule) ([]Resolved, error) {
+	resolved := make([]Resolved, 0, len(rules))
+	for _, rule := range rules {
+		r, err := Resolve(rule, rs.PolicySets(policy))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rule, err)
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+// ParseFlow parses a flow from its parts, as given on the command line or in a CSV file
+func ParseFlow(source, destination, port, protocol string) (Flow, error) {
+	flow := Flow{Protocol: strings.ToUpper(strings.TrimSpace(protocol))}
+	if flow.Protocol == "" {
+		flow.Protocol = "TCP"
+	}
+	var err error
+	if flow.Source, err = netip.ParseAddr(strings.TrimSpace(source)); err != nil {
+		return flow, fmt.Errorf("invalid source address %q", source)
+	}
+	if flow.Destination, err = netip.ParseAddr(strings.TrimSpace(destination)); err != nil {
+		return flow, fmt.Errorf("invalid destination address %q", destination)
+	}
+	if flow.Protocol == "ICMP" {
+		return flow, nil
+	}
+	if flow.Port, err = parsePort(strings.TrimSpace(port)); err != nil {
+		return flow, fmt.Errorf("invalid port %q", port)
+	}
+	return flow, nil
+}
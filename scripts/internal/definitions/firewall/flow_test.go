@@ -0,0 +1,99 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	rules := load(t, testSource(map[string]string{
+		"sets.json": `{"IP_SETS": {"PSN": ["${psn}"]}, "PORT_SETS": {"ORACLE": ["1521:1522"]}}`,
+		"development_rules.json": `{
+			"default_block_development_ingress": {"action": "DROP", "source_ip": "0.0.0.0/0", "destination_ip": "10.26.0.0/16", "destination_port": "ANY", "protocol": "IP"},
+			"psn_to_development_oracle": {"action": "PASS", "source_ip": "$PSN", "destination_ip": "10.26.0.0/16", "destination_port": "$ORACLE", "protocol": "TCP"}
+		}`,
+		"production_rules.json": `{
+			"default_open": {"action": "ALERT", "source_ip": "0.0.0.0/0", "destination_ip": "0.0.0.0/0", "destination_port": "ANY", "protocol": "IP"},
+			"production_to_psn_dns": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "$PSN", "destination_port": "53", "protocol": "UDP"}
+		}`,
+	}))
+	external, err := rules.Context("external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	production, err := rules.Context("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rules    []Resolved
+		flow     []string
+		expected string
+	}{
+		// pass rules are evaluated before drop rules, whatever their sids
+		{external, []string{"51.1.1.1", "10.26.1.1", "1522", "tcp"}, "PASS psn_to_development_oracle"},
+		{external, []string{"51.1.1.1", "10.26.1.1", "1523", "tcp"}, "DROP default_block_development_ingress"},
+		// the drop rule is for any port, so it also matches flows out of development
+		{external, []string{"10.26.1.1", "8.8.8.8", "443", "tcp"}, "DROP default_block_development_ingress"},
+		{external, []string{"10.27.8.1", "51.1.1.1", "53", "udp"}, "PASS production_to_psn_dns"},
+		{external, []string{"10.27.8.1", "51.1.1.1", "53", "tcp"}, "PASS default"},
+		{external, []string{"10.27.8.1", "51.1.1.1", "", "icmp"}, "PASS default"},
+		// a file context only has that file's rules
+		{production, []string{"51.1.1.1", "10.26.1.1", "1523", "tcp"}, "PASS default"},
+	}
+	for _, test := range tests {
+		flow, err := ParseFlow(test.flow[0], test.flow[1], test.flow[2], test.flow[3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		verdict := Evaluate(test.rules, flow)
+		got := verdict.Action + " default"
+		if verdict.Rule != nil {
+			got = verdict.Action + " " + verdict.Rule.Name
+		}
+		if got != test.expected {
+			t.Errorf("%s: got %s, expected %s", flow, got, test.expected)
+		}
+		if len(verdict.Alerts) != 1 || verdict.Alerts[0].Name != "default_open" {
+			t.Errorf("%s: expected the default_open alert, got %v", flow, verdict.Alerts)
+		}
+	}
+
+	if _, err := rules.Context("staging"); err == nil || !strings.Contains(err.Error(), "external, inline, development") {
+		t.Errorf("expected an unknown context error listing the contexts, got %v", err)
+	}
+	if _, err := ParseFlow("10.0.0.1", "10.0.0.2", "", "tcp"); err == nil {
+		t.Error("expected a TCP flow without a port to be an error")
+	}
+}
+
+func TestCovers(t *testing.T) {
+	sets := Sets{
+		IPSets:   map[string][]string{"DCS": {"10.0.0.5/32", "10.0.1.5/32"}},
+		PortSets: map[string][]string{"DC_TCP": {"53", "88", "49152:65535"}},
+	}
+	resolve := func(action, source, destination, port, protocol string) Resolved {
+		t.Helper()
+		rule, err := Resolve(Rule{Name: destination, Action: action, SourceIP: source, DestinationIP: destination, DestinationPort: port, Protocol: protocol}, sets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rule
+	}
+
+	broad := resolve("PASS", "10.0.0.0/16", "$DCS", "ANY", "IP")
+	narrow := resolve("PASS", "10.0.2.0/24", "10.0.0.5/32", "$DC_TCP", "TCP")
+	if !broad.Covers(narrow) || narrow.Covers(broad) {
+		t.Error("expected the broad rule to cover the narrow one, and not the other way round")
+	}
+	if resolve("PASS", "10.0.0.0/16", "$DCS", "443", "UDP").Covers(narrow) {
+		t.Error("a UDP rule doesn't cover TCP")
+	}
+	if resolve("PASS", "10.0.0.0/16", "$DCS", "53:88", "TCP").Covers(narrow) {
+		t.Error("port 49152 is outside 53:88")
+	}
+	if !resolve("PASS", "ANY", "0.0.0.0/0", "ANY", "IP").Covers(broad) {
+		t.Error("expected any to any to cover every rule")
+	}
+}
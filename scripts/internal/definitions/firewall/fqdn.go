@@ -0,0 +1,120 @@
+package firewall
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SharedSuffixes are domains where anyone can create a subdomain, such as a
+// storage account or web app, so allowing every subdomain allows any tenant's
+var SharedSuffixes = []string{
+	"amazonaws.com",
+	"appspot.com",
+	"azureedge.net",
+	"azurewebsites.net",
+	"blob.core.windows.net",
+	"cloudapp.azure.com",
+	"cloudfront.net",
+	"github.io",
+	"googleusercontent.com",
+	"herokuapp.com",
+	"servicebus.windows.net",
+	"trafficmanager.net",
+}
+
+// publicSuffixes are the multi-label public suffixes a wildcard could be mistaken for a domain in
+var publicSuffixes = []string{"ac.uk", "co.uk", "gov.uk", "ltd.uk", "nhs.uk", "org.uk", "plc.uk", "police.uk", "sch.uk"}
+
+// hostnameLabel is a DNS label: letters, digits and hyphens, not starting or ending with a hyphen
+var hostnameLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// DomainMatches reports whether an allow list entry allows host. An entry with
+// a leading dot allows the domain and every subdomain, otherwise only the
+// exact host. Matching is case insensitive.
+func DomainMatches(entry, host string) bool {
+	entry, host = strings.ToLower(entry), strings.ToLower(strings.TrimSuffix(host, "."))
+	if domain, ok := strings.CutPrefix(entry, "."); ok {
+		return host == domain || strings.HasSuffix(host, entry)
+	}
+	return host == entry
+}
+
+// Allowing returns the allow list entries that allow host
+func (f FQDN) Allowing(host string) []string {
+	entries := []string{}
+	for _, entry := range f.AllowedDomains {
+		if DomainMatches(entry, host) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// validHostname returns why an allow list entry isn't a valid domain name, or ""
+func validHostname(entry string) string {
+	name := strings.TrimPrefix(entry, ".")
+	switch {
+	case strings.Contains(entry, "://") || strings.ContainsAny(entry, "/:"):
+		return "is a URL rather than a domain name"
+	case strings.Contains(entry, "*"):
+		return "uses *, use a leading . to allow subdomains"
+	case entry != strings.ToLower(entry):
+		return "is not lower case"
+	case strings.HasSuffix(entry, "."):
+		return "has a trailing ."
+	case len(name) > 253:
+		return "is longer than 253 characters"
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 && !strings.HasPrefix(entry, ".") {
+		return "is not a fully qualified domain name"
+	}
+	for _, label := range labels {
+		if !hostnameLabel.MatchString(label) {
+			return fmt.Sprintf("has an invalid label %q", label)
+		}
+	}
+	return ""
+}
+
+// CheckFQDN checks the allow list. Failures are entries that aren't valid
+// domain names or are listed twice. Warnings are entries already allowed by a
+// wildcard, and wildcards broad enough to allow domains anyone can register.
+func CheckFQDN(f FQDN) (failures, warnings []string) {
+	seen := map[string]bool{}
+	for _, entry := range f.AllowedDomains {
+		if problem := validHostname(entry); problem != "" {
+			failures = append(failures, fmt.Sprintf("%q %s", entry, problem))
+			continue
+		}
+		if seen[entry] {
+			failures = append(failures, fmt.Sprintf("%q is listed more than once", entry))
+			continue
+		}
+		seen[entry] = true
+
+		for _, other := range f.AllowedDomains {
+			if other != entry && strings.HasPrefix(other, ".") && DomainMatches(other, strings.TrimPrefix(entry, ".")) {
+				warnings = append(warnings, fmt.Sprintf("%q is redundant, %q already allows it", entry, other))
+				break
+			}
+		}
+
+		if domain, ok := strings.CutPrefix(entry, "."); ok {
+			switch {
+			case !strings.Contains(domain, "."):
+				warnings = append(warnings, fmt.Sprintf("%q allows every domain in a top level domain", entry))
+			case slices.Contains(publicSuffixes, domain):
+				warnings = append(warnings, fmt.Sprintf("%q allows every domain under a public suffix", entry))
+			case slices.ContainsFunc(SharedSuffixes, func(suffix string) bool { return DomainMatches("."+suffix, domain) }):
+				warnings = append(warnings, fmt.Sprintf("%q allows subdomains anyone can create, list the exact hosts instead", entry))
+			}
+		}
+	}
+	sort.Strings(failures)
+	sort.Strings(warnings)
+	return failures, warnings
+}
@@ -0,0 +1,72 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDomainMatches(t *testing.T) {
+	tests := []struct {
+		entry, host string
+		expected    bool
+	}{
+		{".ubuntu.com", "ubuntu.com", true},
+		{".ubuntu.com", "archive.ubuntu.com", true},
+		{".ubuntu.com", "Security.Ubuntu.com.", true},
+		{".ubuntu.com", "notubuntu.com", false},
+		{"microsoft.com", "microsoft.com", true},
+		{"microsoft.com", "www.microsoft.com", false},
+	}
+	for _, test := range tests {
+		if got := DomainMatches(test.entry, test.host); got != test.expected {
+			t.Errorf("DomainMatches(%q, %q) = %t, expected %t", test.entry, test.host, got, test.expected)
+		}
+	}
+
+	fqdn := FQDN{AllowedDomains: []string{".windowsupdate.com", ".download.windowsupdate.com", "microsoft.com"}}
+	if got := strings.Join(fqdn.Allowing("au.download.windowsupdate.com"), " "); got != ".windowsupdate.com .download.windowsupdate.com" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCheckFQDN(t *testing.T) {
+	failures, warnings := CheckFQDN(FQDN{AllowedDomains: []string{
+		".ubuntu.com",
+		"archive.ubuntu.com",
+		".windowsupdate.com",
+		".download.windowsupdate.com",
+		"microsoft.com",
+		"microsoft.com",
+		".org",
+		".gov.uk",
+		".blob.core.windows.net",
+		"*.docker.io",
+		"https://ghcr.io",
+		"Example.com",
+		"bad_host.example.com",
+		"localhost",
+	}})
+
+	expectedFailures := []string{
+		`"*.docker.io" uses *, use a leading . to allow subdomains`,
+		`"Example.com" is not lower case`,
+		`"bad_host.example.com" has an invalid label "bad_host"`,
+		`"https://ghcr.io" is a URL rather than a domain name`,
+		`"localhost" is not a fully qualified domain name`,
+		`"microsoft.com" is listed more than once`,
+	}
+	if strings.Join(failures, "\n") != strings.Join(expectedFailures, "\n") {
+		t.Errorf("got failures:\n%s\nexpected:\n%s", strings.Join(failures, "\n"), strings.Join(expectedFailures, "\n"))
+	}
+
+	expectedWarnings := []string{
+		`".blob.core.windows.net" allows subdomains anyone can create, list the exact hosts instead`,
+		`".download.windowsupdate.com" is redundant, ".windowsupdate.com" already allows it`,
+		`".gov.uk" allows every domain under a public suffix`,
+		`".org" allows every domain in a top level domain`,
+		`"archive.ubuntu.com" is redundant, ".ubuntu.com" already allows it`,
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("got warnings:\n%s\nexpected:\n%s", strings.Join(warnings, "\n"), strings.Join(expectedWarnings, "\n"))
+	}
+}
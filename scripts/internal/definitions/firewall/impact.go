@@ -0,0 +1,509 @@
+package firewall
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RuleChange is a rule added, removed or changed between two rule sets.
+// Before is nil for an added rule and After is nil for a removed one.
+type RuleChange struct {
+	Name   string
+	Before *Resolved
+	After  *Resolved
+}
+
+// Decision is the action taken on a flow and the rule that decided it, "" for the default action
+type Decision struct {
+	Action string
+	Rule   string
+}
+
+func decision(verdict Verdict) Decision {
+	if verdict.Rule == nil {
+		return Decision{Action: verdict.Action}
+	}
+	return Decision{Action: verdict.Action, Rule: verdict.Rule.Name}
+}
+
+// FlowChange is a block of flows whose action differs between two rule sets
+type FlowChange struct {
+	Protocol     string
+	Sources      []netip.Prefix
+	Destinations []netip.Prefix
+	// Ports is empty for ICMP
+	Ports  []PortRange
+	Before Decision
+	After  Decision
+}
+
+// Impact is the effect of the changes to one policy's rules
+type Impact struct {
+	Policy Policy
+	Rules  []RuleChange
+	// Allowed are flows that were dropped or rejected and are now passed
+	Allowed []FlowChange
+	// Blocked are flows that were passed and are now dropped or rejected
+	Blocked []FlowChange
+}
+
+// Compare returns the impact of the changes between two rule sets on each policy
+func Compare(before, after RuleSet) ([]Impact, error) {
+	impacts := []Impact{}
+	for _, policy := range Policies {
+		beforeRules, err := before.Context(policy.Name)
+		if err != nil {
+			return nil, err
+		}
+		afterRules, err := after.Context(policy.Name)
+		if err != nil {
+			return nil, err
+		}
+		impact := Impact{Policy: policy, Rules: ruleChanges(beforeRules, afterRules)}
+		impact.Allowed, impact.Blocked = flowChanges(beforeRules, afterRules, impact.Rules)
+		impacts = append(impacts, impact)
+	}
+	return impacts, nil
+}
+
+// ruleChanges returns the rules whose resolved header differs, by name
+func ruleChanges(before, after []Resolved) []RuleChange {
+	byName := map[string]*RuleChange{}
+	for _, rule := range before {
+		byName[rule.Name] = &RuleChange{Name: rule.Name, Before: &rule}
+	}
+	for _, rule := range after {
+		if byName[rule.Name] == nil {
+			byName[rule.Name] = &RuleChange{Name: rule.Name}
+		}
+		byName[rule.Name].After = &rule
+	}
+
+	changes := []RuleChange{}
+	for _, change := range byName {
+		if change.Before == nil || change.After == nil || !sameHeader(*change.Before, *change.After) {
+			changes = append(changes, *change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// sameHeader reports whether two rules match the same flows with the same action
+func sameHeader(a, b Resolved) bool {
+	return a.Action == b.Action && a.Protocol == b.Protocol &&
+		reflect.DeepEqual(a.Sources, b.Sources) &&
+		reflect.DeepEqual(a.Destinations, b.Destinations) &&
+		reflect.DeepEqual(a.Ports, b.Ports)
+}
+
+// addrRange is an inclusive range of IPv4 addresses
+type addrRange struct {
+	from, to uint32
+}
+
+func prefixRange(prefix netip.Prefix) addrRange {
+	from := binary.BigEndian.Uint32(prefix.Masked().Addr().AsSlice())
+	return addrRange{from, from | (1<<(32-prefix.Bits()) - 1)}
+}
+
+func addrFrom(value uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], value)
+	return netip.AddrFrom4(b)
+}
+
+// prefixes returns the fewest prefixes that exactly cover the range
+func (r addrRange) prefixes() []netip.Prefix {
+	prefixes := []netip.Prefix{}
+	from := uint64(r.from)
+	for from <= uint64(r.to) {
+		// the largest block aligned at from that doesn't pass to
+		size := 32
+		if from != 0 {
+			size = bits.TrailingZeros64(from)
+		}
+		for size > 0 && from+(1<<size)-1 > uint64(r.to) {
+			size--
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addrFrom(uint32(from)), 32-min(size, 32)))
+		from += 1 << size
+	}
+	return prefixes
+}
+
+// box is a block of flows a rule matches: one source and destination pair of
+// its header, or the reverse of one for a rule for any port
+type box struct {
+	sources, destinations span
+	ports                 []span
+	// side is beforeSide or afterSide, and rule the index of the rule in
+	// that side's rules, or -1 for a changed region
+	side, rule int
+}
+
+// changed is whether the box is one of the changed regions rather than a rule
+func (b box) changed() bool {
+	return b.rule < 0
+}
+
+const (
+	beforeSide = iota
+	afterSide
+)
+
+// span is an inclusive range of addresses or ports
+type span struct {
+	from, to uint64
+}
+
+// block is a set of flows that every rule treats the same way
+type block struct {
+	protocol                     string
+	sources, destinations, ports []span
+	decisions                    [2]Decision
+}
+
+// flowChanges returns the blocks of flows, within the regions the changed rules
+// match, that are newly allowed and newly blocked. Rather than evaluating a grid
+// of every rule's edges, it splits the sources at the edges of the rules in the
+// changed regions, then splits each distinct set of matching rules by
+// destination, then by port, so a broad change only splits on the rules that
+// apply to each part of it.
+func flowChanges(before, after []Resolved, changes []RuleChange) (allowed, blocked []FlowChange) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	regions := changedRegions(changes)
+	protocols := []string{}
+	for _, r := range regions {
+		for _, protocol := range r.protocols {
+			if !slices.Contains(protocols, protocol) {
+				protocols = append(protocols, protocol)
+			}
+		}
+	}
+	sort.Strings(protocols)
+
+	blocks := []block{}
+	for _, protocol := range protocols {
+		blocks = append(blocks, protocolChanges(before, after, regions, protocol)...)
+	}
+	for _, change := range mergeBlocks(blocks) {
+		if change.After.Action == "PASS" {
+			allowed = append(allowed, change)
+		} else {
+			blocked = append(blocked, change)
+		}
+	}
+	return allowed, blocked
+}
+
+// protocolChanges returns the blocks of flows of one protocol whose action changes
+func protocolChanges(before, after []Resolved, regions []region, protocol string) []block {
+	// ports don't apply to ICMP, so every box covers the one port 0
+	ports := func(rule []PortRange) []span {
+		if protocol == "ICMP" {
+			return []span{{0, 0}}
+		}
+		spans := make([]span, 0, len(rule))
+		for _, p := range rule {
+			spans = append(spans, span{uint64(p.From), uint64(p.To)})
+		}
+		return spans
+	}
+
+	boxes := []box{}
+	for _, r := range regions {
+		if slices.Contains(r.protocols, protocol) {
+			boxes = append(boxes, box{r.sources.span(), r.destinations.span(), ports(r.ports), 0, -1})
+		}
+	}
+	changedBoxes := len(boxes)
+	for side, rules := range [][]Resolved{before, after} {
+		for i, rule := range rules {
+			if rule.Protocol != "IP" && !strings.EqualFold(rule.Protocol, protocol) {
+				continue
+			}
+			for _, src := range rule.Sources {
+				for _, dst := range rule.Destinations {
+					forward := box{prefixRange(src).span(), prefixRange(dst).span(), ports(rule.Ports), side, i}
+					// a rule outside every changed region decides none of the changed flows
+					if overlapsAny(forward, boxes[:changedBoxes]) {
+						boxes = append(boxes, forward)
+					}
+					if slices.Contains(rule.Ports, AnyPort) {
+						reverse := box{forward.destinations, forward.sources, forward.ports, side, i}
+						if overlapsAny(reverse, boxes[:changedBoxes]) {
+							boxes = append(boxes, reverse)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	all := make([]int, len(boxes))
+	for i := range boxes {
+		all[i] = i
+	}
+	decided := map[string][2]Decision{}
+	blocks := []block{}
+	for _, sources := range partition(boxes, all, func(b box) []span { return []span{b.sources} }) {
+		for _, destinations := range partition(boxes, sources.members, func(b box) []span { return []span{b.destinations} }) {
+			for _, cell := range partition(boxes, destinations.members, func(b box) []span { return b.ports }) {
+				key := fmt.Sprint(cell.members)
+				decisions, ok := decided[key]
+				if !ok {
+					flow := Flow{
+						Source:      addrFrom(uint32(sources.spans[0].from)),
+						Destination: addrFrom(uint32(destinations.spans[0].from)),
+						Port:        int(cell.spans[0].from),
+						Protocol:    protocol,
+					}
+					// the matching rules of each side, kept in sid order
+					rules := [2][]Resolved{before, after}
+					indexes := [2][]int{}
+					for _, i := range cell.members {
+						if b := boxes[i]; !b.changed() {
+							indexes[b.side] = append(indexes[b.side], b.rule)
+						}
+					}
+					matching := [2][]Resolved{}
+					for side := range indexes {
+						slices.Sort(indexes[side])
+						for _, i := range slices.Compact(indexes[side]) {
+							matching[side] = append(matching[side], rules[side][i])
+						}
+					}
+					decisions = [2]Decision{decision(Evaluate(matching[beforeSide], flow)), decision(Evaluate(matching[afterSide], flow))}
+					decided[key] = decisions
+				}
+				if (decisions[0].Action == "PASS") != (decisions[1].Action == "PASS") {
+					blocks = append(blocks, block{protocol, sources.spans, destinations.spans, cell.spans, decisions})
+				}
+			}
+		}
+	}
+	return blocks
+}
+
+func overlapsAny(b box, regions []box) bool {
+	return slices.ContainsFunc(regions, func(r box) bool {
+		return r.sources.overlaps(b.sources) && r.destinations.overlaps(b.destinations)
+	})
+}
+
+func (s span) overlaps(other span) bool {
+	return s.from <= other.to && other.from <= s.to
+}
+
+func (r addrRange) span() span {
+	return span{uint64(r.from), uint64(r.to)}
+}
+
+// group is the parts of one dimension covered by the same boxes
+type group struct {
+	spans   []span
+	members []int
+}
+
+// partition splits one dimension of the members' boxes at their edges, and
+// groups the parts by the boxes that cover them. Parts no changed region
+// covers are left out.
+func partition(boxes []box, members []int, bounds func(box) []span) []group {
+	type event struct {
+		at    uint64
+		box   int
+		delta int
+	}
+	events := []event{}
+	for _, i := range members {
+		for _, s := range bounds(boxes[i]) {
+			events = append(events, event{s.from, i, 1}, event{s.to + 1, i, -1})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].at < events[j].at })
+
+	groups, order := map[string]*group{}, []string{}
+	active := map[int]int{}
+	for i := 0; i < len(events); {
+		at := events[i].at
+		for ; i < len(events) && events[i].at == at; i++ {
+			if active[events[i].box] += events[i].delta; active[events[i].box] == 0 {
+				delete(active, events[i].box)
+			}
+		}
+		if i == len(events) || len(active) == 0 {
+			continue
+		}
+		covering := make([]int, 0, len(active))
+		changed := false
+		for b := range active {
+			covering = append(covering, b)
+			changed = changed || boxes[b].changed()
+		}
+		if !changed {
+			continue
+		}
+		sort.Ints(covering)
+		key := fmt.Sprint(covering)
+		if groups[key] == nil {
+			groups[key] = &group{members: covering}
+			order = append(order, key)
+		}
+		part := span{at, events[i].at - 1}
+		g := groups[key]
+		if n := len(g.spans); n > 0 && g.spans[n-1].to+1 == part.from {
+			g.spans[n-1].to = part.to
+		} else {
+			g.spans = append(g.spans, part)
+		}
+	}
+
+	partitioned := make([]group, 0, len(order))
+	for _, key := range order {
+		partitioned = append(partitioned, *groups[key])
+	}
+	return partitioned
+}
+
+// region is a block of flows a changed rule matches
+type region struct {
+	protocols    []string
+	sources      addrRange
+	destinations addrRange
+	ports        []PortRange
+}
+
+// changedRegions returns the flows matched by either version of each changed rule.
+// A rule for any port also matches flows in the reverse direction.
+func changedRegions(changes []RuleChange) []region {
+	regions := []region{}
+	for _, change := range changes {
+		for _, rule := range []*Resolved{change.Before, change.After} {
+			if rule == nil {
+				continue
+			}
+			protocols := []string{rule.Protocol}
+			if rule.Protocol == "IP" {
+				protocols = []string{"TCP", "UDP", "ICMP"}
+			}
+			for _, src := range rule.Sources {
+				for _, dst := range rule.Destinations {
+					regions = append(regions, region{protocols, prefixRange(src), prefixRange(dst), rule.Ports})
+					if slices.Contains(rule.Ports, AnyPort) {
+						regions = append(regions, region{protocols, prefixRange(dst), prefixRange(src), rule.Ports})
+					}
+				}
+			}
+		}
+	}
+	return regions
+}
+
+// mergeBlocks joins blocks with the same decisions that differ in only one
+// dimension: first ports, then destinations, then sources
+func mergeBlocks(blocks []block) []FlowChange {
+	merge := func(blocks []block, key func(block) string, join func(into *block, b block)) []block {
+		merged, order := map[string]*block{}, []string{}
+		for _, b := range blocks {
+			k := fmt.Sprintf("%s %v %s", b.protocol, b.decisions, key(b))
+			if merged[k] == nil {
+				copied := b
+				merged[k] = &copied
+				order = append(order, k)
+				continue
+			}
+			join(merged[k], b)
+		}
+		result := make([]block, 0, len(order))
+		for _, k := range order {
+			result = append(result, *merged[k])
+		}
+		return result
+	}
+	blocks = merge(blocks, func(b block) string { return fmt.Sprint(b.sources, b.destinations) }, func(into *block, b block) {
+		into.ports = mergeSpans(slices.Concat(into.ports, b.ports))
+	})
+	blocks = merge(blocks, func(b block) string { return fmt.Sprint(b.sources, b.ports) }, func(into *block, b block) {
+		into.destinations = mergeSpans(slices.Concat(into.destinations, b.destinations))
+	})
+	blocks = merge(blocks, func(b block) string { return fmt.Sprint(b.destinations, b.ports) }, func(into *block, b block) {
+		into.sources = mergeSpans(slices.Concat(into.sources, b.sources))
+	})
+
+	changes := []FlowChange{}
+	for _, b := range blocks {
+		change := FlowChange{Protocol: b.protocol, Before: b.decisions[0], After: b.decisions[1]}
+		for _, s := range mergeSpans(b.sources) {
+			change.Sources = append(change.Sources, addrRange{uint32(s.from), uint32(s.to)}.prefixes()...)
+		}
+		for _, s := range mergeSpans(b.destinations) {
+			change.Destinations = append(change.Destinations, addrRange{uint32(s.from), uint32(s.to)}.prefixes()...)
+		}
+		if b.protocol != "ICMP" {
+			for _, s := range mergeSpans(b.ports) {
+				change.Ports = append(change.Ports, PortRange{int(s.from), int(s.to)})
+			}
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Sources[0] != b.Sources[0] {
+			return a.Sources[0].Addr().Less(b.Sources[0].Addr()) || (a.Sources[0].Addr() == b.Sources[0].Addr() && a.Sources[0].Bits() < b.Sources[0].Bits())
+		}
+		if a.Destinations[0] != b.Destinations[0] {
+			return a.Destinations[0].Addr().Less(b.Destinations[0].Addr()) || (a.Destinations[0].Addr() == b.Destinations[0].Addr() && a.Destinations[0].Bits() < b.Destinations[0].Bits())
+		}
+		return a.Protocol < b.Protocol
+	})
+	return changes
+}
+
+func mergeSpans(spans []span) []span {
+	sorted := slices.Clone(spans)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].from < sorted[j].from })
+	merged := []span{}
+	for _, s := range sorted {
+		if n := len(merged); n > 0 && merged[n-1].to+1 >= s.from {
+			merged[n-1].to = max(merged[n-1].to, s.to)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func portsString(ports []PortRange) string {
+	parts := []string{}
+	for _, p := range ports {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// CompareDomains returns the allow list entries added and removed between two rule sets
+func CompareDomains(before, after FQDN) (added, removed []string) {
+	for _, domain := range after.AllowedDomains {
+		if !slices.Contains(before.AllowedDomains, domain) {
+			added = append(added, domain)
+		}
+	}
+	for _, domain := range before.AllowedDomains {
+		if !slices.Contains(after.AllowedDomains, domain) {
+			removed = append(removed, domain)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
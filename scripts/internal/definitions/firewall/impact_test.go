@@ -0,0 +1,177 @@
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	before := load(t, testSource(map[string]string{
+		"development_rules.json": `{
+			"default_block_development_ingress": {"action": "DROP", "source_ip": "0.0.0.0/0", "destination_ip": "10.26.0.0/16", "destination_port": "ANY", "protocol": "IP"},
+			"psn_to_development_oracle": {"action": "PASS", "source_ip": "${psn}", "destination_ip": "10.26.0.0/16", "destination_port": "1521", "protocol": "TCP"}
+		}`,
+		"fqdn_rules.json": `{"fw_allowed_domains": [".example.org", "api.example.com"]}`,
+	}))
+	after := load(t, testSource(map[string]string{
+		"development_rules.json": `{
+			"default_block_development_ingress": {"action": "DROP", "source_ip": "0.0.0.0/0", "destination_ip": "10.26.0.0/16", "destination_port": "ANY", "protocol": "IP"},
+			"production_to_development_https": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "10.26.8.0/24", "destination_port": "443, 8443:8444", "protocol": "TCP"}
+		}`,
+		"fqdn_rules.json": `{"fw_allowed_domains": [".example.org", "www.example.com"]}`,
+	}))
+
+	impacts, err := Compare(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impacts) != len(Policies) {
+		t.Fatalf("expected an impact for each policy, got %d", len(impacts))
+	}
+	external, inline := impacts[0], impacts[1]
+
+	rules := []string{}
+	for _, change := range external.Rules {
+		rules = append(rules, fmt.Sprintf("%s %t %t", change.Name, change.Before != nil, change.After != nil))
+	}
+	expected := "production_to_development_https false true, psn_to_development_oracle true false"
+	if strings.Join(rules, ", ") != expected {
+		t.Errorf("got rule changes %s, expected %s", strings.Join(rules, ", "), expected)
+	}
+
+	flowChange := func(changes []FlowChange) []string {
+		got := []string{}
+		for _, change := range changes {
+			got = append(got, fmt.Sprintf("%s %v %v %s %s/%s %s/%s", change.Protocol, change.Sources, change.Destinations, portsString(change.Ports),
+				change.Before.Action, change.Before.Rule, change.After.Action, change.After.Rule))
+		}
+		return got
+	}
+	expectedAllowed := "TCP [10.27.8.0/21] [10.26.8.0/24] 443,8443:8444 DROP/default_block_development_ingress PASS/production_to_development_https"
+	if got := flowChange(external.Allowed); strings.Join(got, "\n") != expectedAllowed {
+		t.Errorf("got allowed:\n%s\nexpected:\n%s", strings.Join(got, "\n"), expectedAllowed)
+	}
+	expectedBlocked := "TCP [51.0.0.0/8] [10.26.0.0/16] 1521 PASS/psn_to_development_oracle DROP/default_block_development_ingress"
+	if got := flowChange(external.Blocked); strings.Join(got, "\n") != expectedBlocked {
+		t.Errorf("got blocked:\n%s\nexpected:\n%s", strings.Join(got, "\n"), expectedBlocked)
+	}
+
+	if len(inline.Rules) != 0 || len(inline.Allowed) != 0 || len(inline.Blocked) != 0 {
+		t.Errorf("expected no inline changes, got %+v", inline)
+	}
+
+	added, removed := CompareDomains(before.FQDN, after.FQDN)
+	if strings.Join(added, " ") != "www.example.com" || strings.Join(removed, " ") != "api.example.com" {
+		t.Errorf("got added %v and removed %v", added, removed)
+	}
+}
+
+func TestCompareBroadChange(t *testing.T) {
+	// hundreds of narrow rules between /24s, then a rule changed to pass everything
+	random := rand.New(rand.NewSource(1))
+	subnet := func() string {
+		return fmt.Sprintf("10.%d.%d.0/24", 26+random.Intn(2), random.Intn(64))
+	}
+	rules := map[string]Rule{
+		"default_block_development_ingress": {Action: "DROP", SourceIP: "0.0.0.0/0", DestinationIP: "10.26.0.0/16", DestinationPort: "ANY", Protocol: "IP"},
+		"default_block_production_ingress":  {Action: "DROP", SourceIP: "0.0.0.0/0", DestinationIP: "10.27.0.0/16", DestinationPort: "ANY", Protocol: "IP"},
+	}
+	for i := range 500 {
+		port := fmt.Sprint(1 + random.Intn(9000))
+		if i%5 == 0 {
+			port += fmt.Sprintf(":%d", 9000+random.Intn(1000))
+		}
+		rules[fmt.Sprintf("rule_%03d", i)] = Rule{Action: "PASS", SourceIP: subnet(), DestinationIP: subnet(), DestinationPort: port, Protocol: []string{"TCP", "UDP"}[i%2]}
+	}
+	ruleFile := func() string {
+		content, err := json.Marshal(rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	before := load(t, testSource(map[string]string{"development_rules.json": ruleFile()}))
+	rules["rule_000"] = Rule{Action: "PASS", SourceIP: "0.0.0.0/0", DestinationIP: "0.0.0.0/0", DestinationPort: "ANY", Protocol: "IP"}
+	after := load(t, testSource(map[string]string{"development_rules.json": ruleFile()}))
+
+	done := make(chan []Impact)
+	go func() {
+		impacts, err := Compare(before, after)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- impacts
+	}()
+	var impacts []Impact
+	select {
+	case impacts = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("comparing a change to pass all traffic took more than 10s")
+	}
+	external := impacts[0]
+	if len(external.Allowed) == 0 || len(external.Blocked) != 0 {
+		t.Fatalf("expected only newly allowed flows, got %d allowed and %d blocked", len(external.Allowed), len(external.Blocked))
+	}
+
+	// every sampled flow whose action changes is in exactly one reported block, with its decisions
+	beforeRules, err := before.Context("external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterRules, err := after.Context("external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contains := func(change FlowChange, flow Flow) bool {
+		return change.Protocol == flow.Protocol &&
+			containsAddr(change.Sources, flow.Source) && containsAddr(change.Destinations, flow.Destination) &&
+			(flow.Protocol == "ICMP" || slices.ContainsFunc(change.Ports, func(p PortRange) bool { return p.Contains(flow.Port) }))
+	}
+	address := func() netip.Addr {
+		if random.Intn(4) == 0 {
+			return netip.AddrFrom4([4]byte{byte(random.Intn(256)), byte(random.Intn(256)), byte(random.Intn(256)), byte(random.Intn(256))})
+		}
+		return netip.AddrFrom4([4]byte{10, byte(26 + random.Intn(2)), byte(random.Intn(64)), byte(random.Intn(256))})
+	}
+	for range 5000 {
+		flow := Flow{Source: address(), Destination: address(), Port: random.Intn(10001), Protocol: []string{"TCP", "UDP", "ICMP"}[random.Intn(3)]}
+		was, is := decision(Evaluate(beforeRules, flow)), decision(Evaluate(afterRules, flow))
+		found := []FlowChange{}
+		for _, change := range external.Allowed {
+			if contains(change, flow) {
+				found = append(found, change)
+			}
+		}
+		switch {
+		case was.Action == is.Action && len(found) != 0:
+			t.Errorf("%s is reported as changed, but is %s before and after", flow, was.Action)
+		case was.Action != is.Action && len(found) != 1:
+			t.Errorf("%s changes from %s to %s, but is in %d reported blocks", flow, was.Action, is.Action, len(found))
+		case len(found) == 1 && (found[0].Before != was || found[0].After != is):
+			t.Errorf("%s is reported as %v to %v, expected %v to %v", flow, found[0].Before, found[0].After, was, is)
+		}
+	}
+}
+
+func TestAddrRangePrefixes(t *testing.T) {
+	for _, test := range []struct {
+		from, to string
+		expected string
+	}{
+		{"10.26.0.0", "10.26.255.255", "[10.26.0.0/16]"},
+		{"10.26.8.0", "10.26.23.255", "[10.26.8.0/21 10.26.16.0/21]"},
+		{"10.0.0.1", "10.0.0.6", "[10.0.0.1/32 10.0.0.2/31 10.0.0.4/31 10.0.0.6/32]"},
+		{"0.0.0.0", "255.255.255.255", "[0.0.0.0/0]"},
+	} {
+		r := addrRange{prefixRange(netip.MustParsePrefix(test.from + "/32")).from, prefixRange(netip.MustParsePrefix(test.to + "/32")).to}
+		if got := fmt.Sprint(r.prefixes()); got != test.expected {
+			t.Errorf("%s-%s: got %s, expected %s", test.from, test.to, got, test.expected)
+		}
+	}
+}
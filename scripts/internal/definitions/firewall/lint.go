@@ -0,0 +1,104 @@
+package firewall
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Lint checks every policy's rules. Failures are references that don't
+// resolve, rule names used in more than one file, and actions or protocols
+// that AWS Network Firewall doesn't accept. Warnings are sets no rule uses.
+func Lint(rs RuleSet) (failures, warnings []string) {
+	files := make([]string, 0, len(rs.Undefined))
+	for file := range rs.Undefined {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		for _, name := range rs.Undefined[file] {
+			failures = append(failures, fmt.Sprintf("%s: ${%s} is not a range in %s or a general subnet set", file, name, CidrRangesFile))
+		}
+	}
+
+	// rule name -> the files it is defined in
+	definedIn := map[string][]string{}
+	usedIPSets, usedPortSets := map[string]bool{}, map[string]bool{}
+	for _, policy := range Policies {
+		sets := rs.PolicySets(policy)
+		for _, file := range policy.Files {
+			for _, rule := range rs.Files[file] {
+				definedIn[rule.Name] = append(definedIn[rule.Name], file)
+
+				if !slices.Contains(Actions, rule.Action) {
+					failures = append(failures, fmt.Sprintf("%s: action %q is not one of %s", rule, rule.Action, strings.Join(Actions, ", ")))
+				}
+				if !slices.Contains(Protocols, rule.Protocol) {
+					failures = append(failures, fmt.Sprintf("%s: protocol %q is not a stateful rule protocol", rule, rule.Protocol))
+				}
+
+				if !policy.Sets {
+					if used := variables(rule); len(used) > 0 {
+						failures = append(failures, fmt.Sprintf("%s: uses %s, but the %s policy has no IP or port sets", rule, strings.Join(used, ", "), policy.Name))
+						continue
+					}
+				}
+				for _, value := range []string{rule.SourceIP, rule.DestinationIP} {
+					if name, ok := variable(value); ok {
+						usedIPSets[name] = true
+					}
+				}
+				if name, ok := variable(rule.DestinationPort); ok {
+					usedPortSets[name] = true
+				}
+
+				// undefined ${name} references are already reported
+				if strings.Contains(rule.SourceIP+rule.DestinationIP+rule.DestinationPort, "${") {
+					continue
+				}
+				if _, err := Resolve(rule, sets); err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %s", rule, err))
+				}
+			}
+		}
+	}
+
+	for name, files := range definedIn {
+		if len(files) > 1 {
+			failures = append(failures, fmt.Sprintf("`%s` is defined in %s", name, strings.Join(files, " and ")))
+		}
+	}
+
+	for name, set := range rs.Sets.IPSets {
+		if !usedIPSets[name] {
+			warnings = append(warnings, fmt.Sprintf("IP set %s is not used by any rule", name))
+		}
+		if _, err := resolveAddresses("$"+name, rs.Sets); err != nil && !strings.Contains(strings.Join(set, ""), "${") {
+			failures = append(failures, fmt.Sprintf("sets.json: IP set %s: %s", name, err))
+		}
+	}
+	for name := range rs.Sets.PortSets {
+		if !usedPortSets[name] {
+			warnings = append(warnings, fmt.Sprintf("port set %s is not used by any rule", name))
+		}
+		if _, err := resolvePorts("$"+name, rs.Sets); err != nil {
+			failures = append(failures, fmt.Sprintf("sets.json: port set %s: %s", name, err))
+		}
+	}
+
+	sort.Strings(failures)
+	sort.Strings(warnings)
+	return failures, warnings
+}
+
+// variables returns the $NAME references in a rule
+func variables(rule Rule) []string {
+	used := []string{}
+	for _, value := range []string{rule.SourceIP, rule.DestinationIP, rule.DestinationPort} {
+		if _, ok := variable(value); ok {
+			used = append(used, value)
+		}
+	}
+	return used
+}
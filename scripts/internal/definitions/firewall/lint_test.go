@@ -0,0 +1,132 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo"
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+// testSource is a minimal stack with one range in cidr-ranges.tf and one general subnet set
+func testSource(files map[string]string) repotest.Memory {
+	src := repotest.Memory{
+		CidrRangesFile: `locals {
+  other_cidr_ranges = {
+    psn = "51.0.0.0/8" # comment
+  }
+}`,
+		"environments-networks/hmpps-production.json": `{"cidr": {"subnet_sets": {"general": {"cidr": "10.27.8.0/21", "accounts": []}}}, "options": {}}`,
+	}
+	for name, content := range files {
+		src[Dir+"/"+name] = content
+	}
+	return src
+}
+
+func load(t *testing.T, src repo.Source) RuleSet {
+	t.Helper()
+	rules, err := Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestResolve(t *testing.T) {
+	rules := load(t, testSource(map[string]string{
+		"sets.json": `{"IP_SETS": {"PSN": ["${psn}", "10.0.0.1"]}, "PORT_SETS": {"WEB": ["80, 443", "8000:8080"]}}`,
+		"production_rules.json": `{
+			"hmpps_to_psn": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "$PSN", "destination_port": "$WEB", "protocol": "TCP"}
+		}`,
+	}))
+
+	resolved, err := Resolve(rules.Files["production_rules.json"][0], rules.Sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, prefix := range append(resolved.Sources, resolved.Destinations...) {
+		got = append(got, prefix.String())
+	}
+	for _, port := range resolved.Ports {
+		got = append(got, port.String())
+	}
+	expected := "10.27.8.0/21 51.0.0.0/8 10.0.0.1/32 80 443 8000:8080"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("got %v, expected %s", strings.Join(got, " "), expected)
+	}
+
+	for value, expected := range map[string]string{
+		"$MISSING":   "$MISSING is not a port set",
+		"70000":      `invalid port "70000"`,
+		"443:80":     `invalid port range "443:80"`,
+		"ANY":        "",
+		"1521, 1522": "",
+	} {
+		rule := Rule{SourceIP: "ANY", DestinationIP: "ANY", DestinationPort: value}
+		_, err := Resolve(rule, rules.Sets)
+		if (err == nil && expected != "") || (err != nil && !strings.Contains(err.Error(), expected)) {
+			t.Errorf("%s: got %v, expected %q", value, err, expected)
+		}
+	}
+}
+
+func TestRulesMergeFilesInKeyOrder(t *testing.T) {
+	rules := load(t, testSource(map[string]string{
+		"development_rules.json": `{"b": {"action": "PASS"}, "a": {"action": "PASS"}}`,
+		"production_rules.json":  `{"a": {"action": "DROP"}, "c": {"action": "PASS"}}`,
+	}))
+
+	got := []string{}
+	for _, rule := range rules.Rules(Policies[0]) {
+		got = append(got, rule.Name+"="+rule.Action)
+	}
+	// production_rules.json is merged after development_rules.json, so its a wins
+	if strings.Join(got, " ") != "a=DROP b=PASS c=PASS" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestLint(t *testing.T) {
+	rules := load(t, testSource(map[string]string{
+		"sets.json": `{
+			"IP_SETS": {"PSN": ["${psn}"], "UNUSED": ["10.0.0.0/8"], "BROKEN": ["10.0.0.0/33"]},
+			"PORT_SETS": {"WEB": ["443"], "SPARE": ["22"]}
+		}`,
+		"production_rules.json": `{
+			"valid": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "$PSN", "destination_port": "$WEB", "protocol": "TCP"},
+			"broken_set": {"action": "PASS", "source_ip": "$BROKEN", "destination_ip": "ANY", "destination_port": "ANY", "protocol": "TCP"},
+			"typo": {"action": "ALLOW", "source_ip": "${hmpps-prod}", "destination_ip": "$PSM", "destination_port": "443", "protocol": "TPC"},
+			"repeated": {"action": "PASS", "source_ip": "ANY", "destination_ip": "ANY", "destination_port": "443", "protocol": "TCP"}
+		}`,
+		"live_data_rules.json": `{
+			"repeated": {"action": "PASS", "source_ip": "ANY", "destination_ip": "ANY", "destination_port": "443", "protocol": "TCP"}
+		}`,
+		"inline_rules.json": `{
+			"uses_set": {"action": "PASS", "source_ip": "${psn}", "destination_ip": "0.0.0.0/0", "destination_port": "$WEB", "protocol": "TCP"}
+		}`,
+	}))
+
+	failures, warnings := Lint(rules)
+	expectedFailures := []string{
+		"`repeated` is defined in production_rules.json and live_data_rules.json",
+		"inline_rules.json uses_set: uses $WEB, but the inline policy has no IP or port sets",
+		"production_rules.json broken_set: source_ip: invalid address \"10.0.0.0/33\"",
+		"production_rules.json typo: action \"ALLOW\" is not one of PASS, DROP, REJECT, ALERT",
+		"production_rules.json typo: protocol \"TPC\" is not a stateful rule protocol",
+		"production_rules.json: ${hmpps-prod} is not a range in " + CidrRangesFile + " or a general subnet set",
+		"sets.json: IP set BROKEN: invalid address \"10.0.0.0/33\"",
+	}
+	if strings.Join(failures, "\n") != strings.Join(expectedFailures, "\n") {
+		t.Errorf("got failures:\n%s\nexpected:\n%s", strings.Join(failures, "\n"), strings.Join(expectedFailures, "\n"))
+	}
+
+	expectedWarnings := []string{
+		"IP set UNUSED is not used by any rule",
+		"port set SPARE is not used by any rule",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("got warnings:\n%s\nexpected:\n%s", strings.Join(warnings, "\n"), strings.Join(expectedWarnings, "\n"))
+	}
+}
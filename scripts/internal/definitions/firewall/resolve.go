@@ -0,0 +1,144 @@
+package firewall
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Any matches every address
+var Any = netip.MustParsePrefix("0.0.0.0/0")
+
+// Actions are the stateful rule actions AWS Network Firewall accepts
+var Actions = []string{"PASS", "DROP", "REJECT", "ALERT"}
+
+// Protocols are the stateful rule header protocols AWS Network Firewall accepts
+var Protocols = []string{
+	"IP", "TCP", "UDP", "ICMP", "HTTP", "FTP", "TLS", "SMB", "DNS", "DCERPC", "SSH", "SMTP",
+	"IMAP", "MSN", "KRB5", "IKEV2", "TFTP", "NTP", "DHCP", "HTTP2", "QUIC",
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	From, To int
+}
+
+// AnyPort matches every port
+var AnyPort = PortRange{0, 65535}
+
+func (p PortRange) Contains(port int) bool {
+	return p.From <= port && port <= p.To
+}
+
+func (p PortRange) String() string {
+	if p.From == p.To {
+		return strconv.Itoa(p.From)
+	}
+	return fmt.Sprintf("%d:%d", p.From, p.To)
+}
+
+// Resolved is a rule with its addresses and ports resolved from sets
+type Resolved struct {
+	Rule
+	Sources      []netip.Prefix
+	Destinations []netip.Prefix
+	Ports        []PortRange
+}
+
+// Resolve resolves a rule's $NAME references against sets, and parses its addresses and ports
+func Resolve(rule Rule, sets Sets) (Resolved, error) {
+	resolved := Resolved{Rule: rule}
+	var err error
+	if resolved.Sources, err = resolveAddresses(rule.SourceIP, sets); err != nil {
+		return resolved, fmt.Errorf("source_ip: %w", err)
+	}
+	if resolved.Destinations, err = resolveAddresses(rule.DestinationIP, sets); err != nil {
+		return resolved, fmt.Errorf("destination_ip: %w", err)
+	}
+	if resolved.Ports, err = resolvePorts(rule.DestinationPort, sets); err != nil {
+		return resolved, fmt.Errorf("destination_port: %w", err)
+	}
+	return resolved, nil
+}
+
+// variable returns the set name a value references, if it is a $NAME reference
+func variable(value string) (string, bool) {
+	if strings.HasPrefix(value, "$") && !strings.HasPrefix(value, "${") {
+		return strings.TrimPrefix(value, "$"), true
+	}
+	return "", false
+}
+
+func resolveAddresses(value string, sets Sets) ([]netip.Prefix, error) {
+	if strings.EqualFold(value, "ANY") {
+		return []netip.Prefix{Any}, nil
+	}
+	values := []string{value}
+	if name, ok := variable(value); ok {
+		set, ok := sets.IPSets[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not an IP set", value)
+		}
+		values = set
+	}
+
+	prefixes := []netip.Prefix{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			// a single address is allowed without a mask
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid address %q", value)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		// Suricata ignores host bits, e.g. 10.172.68.146/29 matches 10.172.68.144/29
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func resolvePorts(value string, sets Sets) ([]PortRange, error) {
+	if strings.EqualFold(value, "ANY") {
+		return []PortRange{AnyPort}, nil
+	}
+	values := []string{value}
+	if name, ok := variable(value); ok {
+		set, ok := sets.PortSets[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a port set", value)
+		}
+		values = set
+	}
+
+	ranges := []PortRange{}
+	for _, value := range values {
+		for _, port := range strings.Split(value, ",") {
+			port = strings.TrimSpace(port)
+			bounds := strings.SplitN(port, ":", 2)
+			from, err := parsePort(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q", port)
+			}
+			to := from
+			if len(bounds) == 2 {
+				if to, err = parsePort(bounds[1]); err != nil || to < from {
+					return nil, fmt.Errorf("invalid port range %q", port)
+				}
+			}
+			ranges = append(ranges, PortRange{from, to})
+		}
+	}
+	return ranges, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return port, nil
+}
@@ -0,0 +1,99 @@
+package firewall
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteSuricata writes the Suricata equivalent of a policy's two stateful rule
+// groups, as the firewall-policy module creates them: the rules, with sids in
+// map key order and their IP and port sets as variables, then the FQDN allow
+// list. AWS Network Firewall generates the allow list rules itself, so these
+// follow its documented form rather than being an exact copy.
+func (rs RuleSet) WriteSuricata(w io.Writer, policy Policy) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s policy, generated from %s\n", policy.Name, Dir)
+
+	rules := rs.Rules(policy)
+	fmt.Fprintf(&b, "\n# stateful rule group: %d rules\n", len(rules))
+	sets := rs.PolicySets(policy)
+	for _, name := range sortedKeys(sets.IPSets) {
+		fmt.Fprintf(&b, "# ipvar %s [%s]\n", name, strings.Join(sets.IPSets[name], ","))
+	}
+	for _, name := range sortedKeys(sets.PortSets) {
+		fmt.Fprintf(&b, "# portvar %s [%s]\n", name, strings.ReplaceAll(strings.Join(sets.PortSets[name], ","), " ", ""))
+	}
+	for i, rule := range rules {
+		fmt.Fprintf(&b, "\n# %s\n%s\n", rule, SuricataRule(rule, i+1))
+	}
+
+	fmt.Fprintf(&b, "\n# FQDN rule group: %d domains\n", len(rs.FQDN.AllowedDomains))
+	fmt.Fprintf(&b, "# ipvar HOME_NET [%s]\n", strings.Join(rs.FQDN.HomeNetIPs, ","))
+	b.WriteString("\n" + strings.Join(FQDNRules(rs.FQDN.AllowedDomains), "\n") + "\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SuricataRule returns the Suricata rule for a 5-tuple stateful rule. The
+// module sets direction ANY and source port ANY on every rule.
+func SuricataRule(rule Rule, sid int) string {
+	return fmt.Sprintf("%s %s %s any <> %s %s (sid:%d;)",
+		strings.ToLower(rule.Action),
+		strings.ToLower(rule.Protocol),
+		suricataValue(rule.SourceIP),
+		suricataValue(rule.DestinationIP),
+		suricataValue(rule.DestinationPort),
+		sid,
+	)
+}
+
+// suricataValue writes ANY as any and a comma separated list as a Suricata
+// list, e.g. "80, 443" as [80,443], leaving single values and $NAME variables alone
+func suricataValue(value string) string {
+	if strings.EqualFold(value, "ANY") {
+		return "any"
+	}
+	value = strings.ReplaceAll(value, " ", "")
+	if strings.Contains(value, ",") {
+		return "[" + value + "]"
+	}
+	return value
+}
+
+// FQDNRules returns the rules an ALLOWLIST domain list with HTTP_HOST and
+// TLS_SNI targets generates: a pass rule per domain and protocol, then a drop
+// rule per protocol for everything else. A leading dot allows the domain and
+// any subdomain; otherwise only the exact host is allowed.
+func FQDNRules(domains []string) []string {
+	rules := []string{}
+	sid := 1
+	for _, target := range []struct{ protocol, keyword string }{{"tls", "tls.sni"}, {"http", "http.host"}} {
+		for _, domain := range domains {
+			match := fmt.Sprintf(`content:"%s"; startswith; endswith;`, domain)
+			if strings.HasPrefix(domain, ".") {
+				match = fmt.Sprintf(`dotprefix; content:"%s"; endswith;`, domain)
+			}
+			rules = append(rules, fmt.Sprintf(`pass %s $HOME_NET any -> $EXTERNAL_NET any (%s; %s nocase; msg:"matching %s allowlisted FQDNs"; flow:to_server, established; sid:%d; rev:1;)`,
+				target.protocol, target.keyword, match, strings.ToUpper(target.protocol), sid))
+			sid++
+		}
+	}
+	for _, protocol := range []string{"tls", "http"} {
+		rules = append(rules, fmt.Sprintf(`drop %s $HOME_NET any -> $EXTERNAL_NET any (msg:"not matching any %s allowlisted FQDNs"; flow:to_server, established; sid:%d; rev:1;)`,
+			protocol, strings.ToUpper(protocol), sid))
+		sid++
+	}
+	return rules
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
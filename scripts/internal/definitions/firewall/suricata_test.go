@@ -0,0 +1,155 @@
+package firewall
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites it with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s, run go test ./firewall -update to create it", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("%s is out of date, run go test ./firewall -update and review the diff", path)
+	}
+}
+
+func TestWriteSuricata(t *testing.T) {
+	rules := load(t, testSource(map[string]string{
+		"sets.json":       `{"IP_SETS": {"PSN": ["${psn}", "10.0.0.1/32"]}, "PORT_SETS": {"WEB": ["80, 443", "8000:8080"]}}`,
+		"fqdn_rules.json": `{"fw_allowed_domains": [".ubuntu.com", "microsoft.com"], "fw_home_net_ips": ["10.26.0.0/16", "10.27.0.0/16"]}`,
+		"development_rules.json": `{
+			"default_block_development_ingress": {"action": "DROP", "source_ip": "0.0.0.0/0", "destination_ip": "10.26.0.0/16", "destination_port": "ANY", "protocol": "IP"}
+		}`,
+		"production_rules.json": `{
+			"production_to_psn_web": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "$PSN", "destination_port": "$WEB", "protocol": "TCP"}
+		}`,
+		"inline_rules.json": `{
+			"production_to_internet_https": {"action": "PASS", "source_ip": "${hmpps-production}", "destination_ip": "0.0.0.0/0", "destination_port": "443", "protocol": "TCP"}
+		}`,
+	}))
+
+	for _, policy := range Policies {
+		var b bytes.Buffer
+		if err := rules.WriteSuricata(&b, policy); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, policy.Name+".rules", b.Bytes())
+	}
+}
+
+func TestSuricataRule(t *testing.T) {
+	tests := []struct {
+		rule     Rule
+		expected string
+	}{
+		{
+			Rule{Action: "PASS", SourceIP: "10.26.8.0/21", DestinationIP: "10.27.0.0/16", DestinationPort: "443", Protocol: "TCP"},
+			"pass tcp 10.26.8.0/21 any <> 10.27.0.0/16 443 (sid:1;)",
+		},
+		{
+			Rule{Action: "DROP", SourceIP: "0.0.0.0/0", DestinationIP: "10.26.0.0/16", DestinationPort: "ANY", Protocol: "IP"},
+			"drop ip 0.0.0.0/0 any <> 10.26.0.0/16 any (sid:1;)",
+		},
+		{
+			Rule{Action: "ALERT", SourceIP: "ANY", DestinationIP: "any", DestinationPort: "any", Protocol: "ICMP"},
+			"alert icmp any any <> any any (sid:1;)",
+		},
+		{
+			Rule{Action: "PASS", SourceIP: "$PSN", DestinationIP: "10.27.8.0/21", DestinationPort: "$WEB", Protocol: "TCP"},
+			"pass tcp $PSN any <> 10.27.8.0/21 $WEB (sid:1;)",
+		},
+		{
+			Rule{Action: "PASS", SourceIP: "10.26.8.0/21", DestinationIP: "10.27.0.0/16", DestinationPort: "80, 443", Protocol: "TCP"},
+			"pass tcp 10.26.8.0/21 any <> 10.27.0.0/16 [80,443] (sid:1;)",
+		},
+		{
+			Rule{Action: "PASS", SourceIP: "10.26.8.0/21", DestinationIP: "10.27.0.0/16", DestinationPort: "1521:1522,5500", Protocol: "TCP"},
+			"pass tcp 10.26.8.0/21 any <> 10.27.0.0/16 [1521:1522,5500] (sid:1;)",
+		},
+		{
+			Rule{Action: "REJECT", SourceIP: "10.26.8.0/21, 10.26.16.0/21", DestinationIP: "10.27.0.0/16", DestinationPort: "22", Protocol: "SSH"},
+			"reject ssh [10.26.8.0/21,10.26.16.0/21] any <> 10.27.0.0/16 22 (sid:1;)",
+		},
+	}
+	for _, test := range tests {
+		if rendered := SuricataRule(test.rule, 1); rendered != test.expected {
+			t.Errorf("got %q, expected %q", rendered, test.expected)
+		}
+	}
+}
+
+// TestSuricataRuleRoundTrip parses each of the repository's rules back from
+// its rendered header and checks it resolves to the same traffic as the JSON
+// rule, with sids in order
+func TestSuricataRuleRoundTrip(t *testing.T) {
+	root, err := repo.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := load(t, repo.WorkTree{Root: root})
+	header := regexp.MustCompile(`^(\w+) (\w+) (\S+) any <> (\S+) (\S+) \(sid:(\d+);\)$`)
+	unlist := func(value string) string { return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]") }
+
+	for _, policy := range Policies {
+		sets := rules.PolicySets(policy)
+		for i, rule := range rules.Rules(policy) {
+			rendered := SuricataRule(rule, i+1)
+			match := header.FindStringSubmatch(rendered)
+			if match == nil {
+				t.Errorf("%s: %q is not a 5-tuple rule", rule, rendered)
+				continue
+			}
+			parsed, err := Resolve(Rule{
+				Action:          strings.ToUpper(match[1]),
+				Protocol:        strings.ToUpper(match[2]),
+				SourceIP:        unlist(match[3]),
+				DestinationIP:   unlist(match[4]),
+				DestinationPort: unlist(match[5]),
+			}, sets)
+			if err != nil {
+				t.Errorf("%s: %q: %s", rule, rendered, err)
+				continue
+			}
+			expected, err := Resolve(rule, sets)
+			if err != nil {
+				t.Errorf("%s: %s", rule, err)
+				continue
+			}
+
+			if parsed.Action != strings.ToUpper(rule.Action) || parsed.Protocol != strings.ToUpper(rule.Protocol) ||
+				!reflect.DeepEqual(parsed.Sources, expected.Sources) ||
+				!reflect.DeepEqual(parsed.Destinations, expected.Destinations) ||
+				!reflect.DeepEqual(parsed.Ports, expected.Ports) {
+				t.Errorf("%s: %q doesn't match the rule", rule, rendered)
+			}
+			if match[6] != strconv.Itoa(i+1) {
+				t.Errorf("%s: %q has sid %s, expected %d", rule, rendered, match[6], i+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runFirewallImpact(args []string) error {
+	flags, repoRoot := newFlagSet("firewall-impact")
+	from := flags.String("from", "", "git ref to compare from, e.g. main")
+	to := flags.String("to", "", "git ref to compare to, defaults to the working tree")
+	maxRows := flags.Int("max-rows", 50, "the most flow changes to list in each table")
+	flags.Parse(args)
+
+	if *from == "" {
+		return errors.New("firewall-impact: --from is required")
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	beforeSrc, err := repo.Open(root, *from)
+	if err != nil {
+		return err
+	}
+	afterSrc, err := repo.Open(root, *to)
+	if err != nil {
+		return err
+	}
+	before, err := firewall.Load(beforeSrc)
+	if err != nil {
+		return err
+	}
+	after, err := firewall.Load(afterSrc)
+	if err != nil {
+		return err
+	}
+
+	impacts, err := firewall.Compare(before, after)
+	if err != nil {
+		return err
+	}
+	added, removed := firewall.CompareDomains(before.FQDN, after.FQDN)
+	return writeFirewallImpact(os.Stdout, fmt.Sprintf("%s...%s", beforeSrc, afterSrc), impacts, added, removed, *maxRows)
+}
+
+// writeFirewallImpact writes the impact as markdown, suitable for a pull request comment
+func writeFirewallImpact(w io.Writer, title string, impacts []firewall.Impact, addedDomains, removedDomains []string, maxRows int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Firewall rule impact: %s\n", title)
+
+	for _, impact := range impacts {
+		fmt.Fprintf(&b, "\n### %s policy\n\n", impact.Policy.Name)
+		if len(impact.Rules) == 0 {
+			b.WriteString("No rule changes.\n")
+			continue
+		}
+
+		for _, change := range impact.Rules {
+			switch {
+			case change.Before == nil:
+				fmt.Fprintf(&b, "- added `%s` in %s\n", change.Name, change.After.File)
+			case change.After == nil:
+				fmt.Fprintf(&b, "- removed `%s` from %s\n", change.Name, change.Before.File)
+			default:
+				fmt.Fprintf(&b, "- changed `%s` in %s\n", change.Name, change.After.File)
+			}
+		}
+
+		if len(impact.Allowed) == 0 && len(impact.Blocked) == 0 {
+			b.WriteString("\nNo flows are newly allowed or blocked.\n")
+		}
+		writeFlowChanges(&b, "Newly allowed", impact.Allowed, maxRows)
+		writeFlowChanges(&b, "Newly blocked", impact.Blocked, maxRows)
+	}
+
+	if len(addedDomains) > 0 || len(removedDomains) > 0 {
+		b.WriteString("\n### FQDN allow list\n\n")
+		for _, domain := range addedDomains {
+			fmt.Fprintf(&b, "- added `%s`\n", domain)
+		}
+		for _, domain := range removedDomains {
+			fmt.Fprintf(&b, "- removed `%s`\n", domain)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFlowChanges(b *strings.Builder, heading string, changes []firewall.FlowChange, maxRows int) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n#### %s\n\n", heading)
+	b.WriteString("| protocol | source | destination | port | before | after |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for i, change := range changes {
+		if i == maxRows {
+			fmt.Fprintf(b, "\n...and %d more\n", len(changes)-maxRows)
+			break
+		}
+		ports := []string{}
+		for _, port := range change.Ports {
+			ports = append(ports, port.String())
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s |\n", change.Protocol, prefixList(change.Sources), prefixList(change.Destinations),
+			strings.Join(ports, ", "), describeDecision(change.Before), describeDecision(change.After))
+	}
+}
+
+func prefixList(prefixes []netip.Prefix) string {
+	if len(prefixes) == 1 && prefixes[0] == firewall.Any {
+		return "any"
+	}
+	parts := []string{}
+	for _, prefix := range prefixes {
+		parts = append(parts, prefix.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeDecision(decision firewall.Decision) string {
+	if decision.Rule == "" {
+		return decision.Action + " (default)"
+	}
+	return fmt.Sprintf("%s `%s`", decision.Action, decision.Rule)
+}
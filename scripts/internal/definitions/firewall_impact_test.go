@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/firewall"
+)
+
+func TestWriteFirewallImpact(t *testing.T) {
+	change := firewall.FlowChange{
+		Protocol:     "TCP",
+		Sources:      []netip.Prefix{firewall.Any},
+		Destinations: []netip.Prefix{netip.MustParsePrefix("10.26.8.0/24")},
+		Ports:        []firewall.PortRange{{From: 443, To: 443}, {From: 8443, To: 8444}},
+		Before:       firewall.Decision{Action: "DROP", Rule: "default_block"},
+		After:        firewall.Decision{Action: "PASS"},
+	}
+	impacts := []firewall.Impact{
+		{
+			Policy:  firewall.Policies[0],
+			Rules:   []firewall.RuleChange{{Name: "default_block", Before: &firewall.Resolved{Rule: firewall.Rule{File: "development_rules.json"}}}},
+			Allowed: []firewall.FlowChange{change, change},
+		},
+		{Policy: firewall.Policies[1]},
+	}
+
+	var out bytes.Buffer
+	if err := writeFirewallImpact(&out, "main...working tree", impacts, []string{"www.example.com"}, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"## Firewall rule impact: main...working tree",
+		"",
+		"### external policy",
+		"",
+		"- removed `default_block` from development_rules.json",
+		"",
+		"#### Newly allowed",
+		"",
+		"| protocol | source | destination | port | before | after |",
+		"| --- | --- | --- | --- | --- | --- |",
+		"| TCP | any | 10.26.8.0/24 | 443, 8443:8444 | DROP `default_block` | PASS (default) |",
+		"",
+		"...and 1 more",
+		"",
+		"### inline policy",
+		"",
+		"No rule changes.",
+		"",
+		"### FQDN allow list",
+		"",
+		"- added `www.example.com`",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
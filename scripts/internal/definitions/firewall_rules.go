@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runFirewallRules(args []string) error {
+	flags, repoRoot := newFlagSet("firewall-rules")
+	policyName := flags.String("policy", "", "only show one policy: external or inline")
+	format := flags.String("format", "text", "output format: text or json")
+	ref := flags.String("ref", "", "read the rules at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	rules, err := firewall.Load(src)
+	if err != nil {
+		return err
+	}
+
+	resolved := []resolvedRule{}
+	found := false
+	for _, policy := range firewall.Policies {
+		if *policyName != "" && policy.Name != *policyName {
+			continue
+		}
+		found = true
+		policyRules, err := rules.Context(policy.Name)
+		if err != nil {
+			return fmt.Errorf("%w, run check-firewall for every problem", err)
+		}
+		for _, rule := range policyRules {
+			resolved = append(resolved, newResolvedRule(policy.Name, rule))
+		}
+	}
+	if !found {
+		return fmt.Errorf("firewall-rules: unknown policy %q", *policyName)
+	}
+
+	switch *format {
+	case "text":
+		return writeFirewallRulesText(os.Stdout, resolved)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(resolved)
+	default:
+		return fmt.Errorf("firewall-rules: unknown format %q, expected text or json", *format)
+	}
+}
+
+// resolvedRule is a rule with its sets and ranges expanded, for output
+type resolvedRule struct {
+	Policy       string   `json:"policy"`
+	File         string   `json:"file"`
+	Name         string   `json:"name"`
+	Action       string   `json:"action"`
+	Protocol     string   `json:"protocol"`
+	Sources      []string `json:"sources"`
+	Destinations []string `json:"destinations"`
+	Ports        []string `json:"ports"`
+}
+
+func newResolvedRule(policy string, r firewall.Resolved) resolvedRule {
+	prefixes := func(values []netip.Prefix) []string {
+		strs := []string{}
+		for _, value := range values {
+			strs = append(strs, value.String())
+		}
+		return strs
+	}
+	ports := []string{}
+	for _, port := range r.Ports {
+		ports = append(ports, port.String())
+	}
+	return resolvedRule{
+		Policy:       policy,
+		File:         r.File,
+		Name:         r.Name,
+		Action:       r.Action,
+		Protocol:     r.Protocol,
+		Sources:      prefixes(r.Sources),
+		Destinations: prefixes(r.Destinations),
+		Ports:        ports,
+	}
+}
+
+func writeFirewallRulesText(w io.Writer, rules []resolvedRule) error {
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "POLICY\tNAME\tACTION\tPROTOCOL\tSOURCE\tDESTINATION\tPORT")
+	for _, rule := range rules {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rule.Policy, rule.Name, rule.Action, rule.Protocol,
+			strings.Join(rule.Sources, ","), strings.Join(rule.Destinations, ","), strings.Join(rule.Ports, ","))
+	}
+	return writer.Flush()
+}
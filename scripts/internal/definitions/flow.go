@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runFlow(args []string) error {
+	flags, repoRoot := newFlagSet("flow")
+	source := flags.String("source", "", "source IP address")
+	destination := flags.String("destination", "", "destination IP address")
+	port := flags.String("port", "", "destination port, not needed for ICMP")
+	protocol := flags.String("protocol", "TCP", "protocol, e.g. TCP, UDP or ICMP")
+	context := flags.String("context", "external", "firewall policy or rule file to evaluate: "+strings.Join(firewall.Contexts(), ", "))
+	batch := flags.String("csv", "", "check every flow in a CSV file with columns source,destination,port,protocol and an optional context")
+	ref := flags.String("ref", "", "read the rules at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	rules, err := firewall.Load(src)
+	if err != nil {
+		return err
+	}
+
+	if *batch != "" {
+		file, err := os.Open(*batch)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return checkFlows(rules, file, os.Stdout, *context)
+	}
+
+	if *source == "" || *destination == "" {
+		return errors.New("flow: --source and --destination are required, or use --csv")
+	}
+	flow, err := firewall.ParseFlow(*source, *destination, *port, *protocol)
+	if err != nil {
+		return err
+	}
+	resolved, err := rules.Context(*context)
+	if err != nil {
+		return err
+	}
+	verdict := firewall.Evaluate(resolved, flow)
+	fmt.Printf("%s in %s\n", flow, *context)
+	fmt.Println(describeVerdict(verdict))
+	for _, alert := range verdict.Alerts {
+		fmt.Println("alert:", alert.Rule)
+	}
+	return nil
+}
+
+// describeVerdict says what happens to a flow and why
+func describeVerdict(verdict firewall.Verdict) string {
+	if verdict.Rule == nil {
+		return verdict.Action + " by default, no rule matches (HTTP and TLS are still limited to the FQDN allow list)"
+	}
+	return verdict.Action + " by " + verdict.Rule.Rule.String()
+}
+
+// checkFlows evaluates each flow in a CSV file with a header row, writing the
+// flows back out with the action and the rule that decided it
+func checkFlows(rules firewall.RuleSet, r io.Reader, w io.Writer, defaultContext string) error {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return errors.New("flow: the CSV file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"source", "destination", "port"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("flow: the CSV file has no %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	contexts := map[string][]firewall.Resolved{}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"source", "destination", "port", "protocol", "context", "action", "rule"})
+	for line, record := range records[1:] {
+		flow, err := firewall.ParseFlow(field(record, "source"), field(record, "destination"), field(record, "port"), field(record, "protocol"))
+		if err != nil {
+			return fmt.Errorf("flow: line %d: %w", line+2, err)
+		}
+		context := field(record, "context")
+		if context == "" {
+			context = defaultContext
+		}
+		if _, ok := contexts[context]; !ok {
+			if contexts[context], err = rules.Context(context); err != nil {
+				return fmt.Errorf("flow: line %d: %w", line+2, err)
+			}
+		}
+
+		verdict := firewall.Evaluate(contexts[context], flow)
+		rule := ""
+		if verdict.Rule != nil {
+			rule = verdict.Rule.Name
+		}
+		writer.Write([]string{flow.Source.String(), flow.Destination.String(), field(record, "port"), flow.Protocol, context, verdict.Action, rule})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func TestCheckFlows(t *testing.T) {
+	rules, err := firewall.Load(repotest.Memory{
+		firewall.CidrRangesFile: `psn = "51.0.0.0/8"`,
+		firewall.Dir + "/production_rules.json": `{
+			"production_to_psn_https": {"action": "PASS", "source_ip": "10.27.8.0/21", "destination_ip": "${psn}", "destination_port": "443", "protocol": "TCP"}
+		}`,
+		firewall.Dir + "/inline_rules.json": `{
+			"block_psn": {"action": "DROP", "source_ip": "10.27.8.0/21", "destination_ip": "${psn}", "destination_port": "ANY", "protocol": "IP"}
+		}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "source,destination,port,protocol,context\n" +
+		"10.27.8.1,51.1.1.1,443,TCP,\n" +
+		"10.27.8.1,51.1.1.1,443,,inline\n" +
+		"10.27.8.1,51.1.1.1,,ICMP,\n"
+	var out bytes.Buffer
+	if err := checkFlows(rules, strings.NewReader(input), &out, "external"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "source,destination,port,protocol,context,action,rule\n" +
+		"10.27.8.1,51.1.1.1,443,TCP,external,PASS,production_to_psn_https\n" +
+		"10.27.8.1,51.1.1.1,443,TCP,inline,DROP,block_psn\n" +
+		"10.27.8.1,51.1.1.1,,ICMP,external,PASS,\n"
+	if out.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+
+	err = checkFlows(rules, strings.NewReader("source,destination,port\n10.27.8.1,51.1.1.1,https\n"), &out, "external")
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error for line 2, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"modernisation-platform/definitions/collaborators"
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/jsonfmt"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// formatTarget is a set of definition files sharing a schema
+type formatTarget struct {
+	dir    string
+	file   string
+	schema *jsonfmt.Schema
+}
+
+var formatTargets = []formatTarget{
+	{dir: environments.Dir, schema: environments.Schema},
+	{dir: networks.Dir, schema: networks.Schema},
+	{file: collaborators.File, schema: collaborators.Schema},
+}
+
+func runFmt(args []string) error {
+	flags, repoRoot := newFlagSet("fmt")
+	check := flags.Bool("check", false, "list files that are not formatted and exit non-zero instead of rewriting them")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+
+	unformatted, err := formatDefinitions(repo.WorkTree{Root: root}, !*check)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range unformatted {
+		fmt.Println(name)
+	}
+	if *check && len(unformatted) > 0 {
+		return fmt.Errorf("fmt: %d file(s) are not formatted, run `go run . fmt` in scripts/internal/definitions", len(unformatted))
+	}
+	return nil
+}
+
+// formatDefinitions returns the definition files that aren't in canonical
+// form, rewriting them when write is set
+func formatDefinitions(tree repo.WorkTree, write bool) ([]string, error) {
+	unformatted := []string{}
+	for _, target := range formatTargets {
+		names := []string{target.file}
+		if target.dir != "" {
+			files, err := tree.ListFiles(target.dir, ".json")
+			if err != nil {
+				return nil, err
+			}
+			names = names[:0]
+			for _, file := range files {
+				names = append(names, target.dir+"/"+file)
+			}
+		}
+
+		for _, name := range names {
+			original, err := tree.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			formatted, err := jsonfmt.Format(original, target.schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if bytes.Equal(original, formatted) {
+				continue
+			}
+
+			unformatted = append(unformatted, name)
+			if write {
+				if err := os.WriteFile(filepath.Join(tree.Root, filepath.FromSlash(name)), formatted, 0644); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return unformatted, nil
+}
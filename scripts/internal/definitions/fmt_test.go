@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo"
+)
+
+func TestFormatDefinitions(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"environments/formatted.json":               "{\n  \"account-type\": \"member\",\n  \"environments\": []\n}\n",
+		"environments/unformatted.json":             "{\n\"environments\": [], \"account-type\": \"member\"}",
+		"environments-networks/hq-development.json": `{"options": {"bastion_linux": false}, "cidr": {"subnet_sets": {}}}`,
+		"collaborators.json":                        "{\n  \"users\": []\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree := repo.WorkTree{Root: root}
+
+	unformatted, err := formatDefinitions(tree, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "environments/unformatted.json,environments-networks/hq-development.json"
+	if strings.Join(unformatted, ",") != expected {
+		t.Fatalf("got %v, expected %s", unformatted, expected)
+	}
+
+	// check mode must not modify anything
+	content, _ := tree.ReadFile("environments/unformatted.json")
+	if string(content) != files["environments/unformatted.json"] {
+		t.Error("check mode rewrote a file")
+	}
+
+	if _, err := formatDefinitions(tree, true); err != nil {
+		t.Fatal(err)
+	}
+	content, _ = tree.ReadFile("environments/unformatted.json")
+	if string(content) != files["environments/formatted.json"] {
+		t.Errorf("got:\n%s\nexpected the canonical form", content)
+	}
+
+	if unformatted, _ := formatDefinitions(tree, false); len(unformatted) != 0 {
+		t.Errorf("expected every file to be formatted, got %v", unformatted)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runFQDN(args []string) error {
+	if len(args) == 0 || (args[0] != "check" && args[0] != "test") {
+		return errors.New("usage: definitions fqdn check [flags] | definitions fqdn test <hostname> [flags]")
+	}
+	action, args := args[0], args[1:]
+
+	// the hostname can come before or after the flags
+	host := ""
+	if action == "test" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		host, args = args[0], args[1:]
+	}
+	flags, repoRoot := newFlagSet("fqdn " + action)
+	ref := flags.String("ref", "", "read the allow list at a git ref instead of the working tree")
+	flags.Parse(args)
+	if action == "test" && host == "" {
+		host = flags.Arg(0)
+	}
+	if action == "test" && host == "" {
+		return errors.New("fqdn test: a hostname is required")
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	rules, err := firewall.Load(src)
+	if err != nil {
+		return err
+	}
+
+	if action == "test" {
+		return testFQDN(os.Stdout, rules.FQDN, host)
+	}
+	failures, warnings := firewall.CheckFQDN(rules.FQDN)
+	for _, warning := range warnings {
+		fmt.Println("warning:", warning)
+	}
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("fqdn check: %d failure(s)", len(failures))
+	}
+	fmt.Printf("%d allowed domains are valid\n", len(rules.FQDN.AllowedDomains))
+	return nil
+}
+
+// testFQDN writes which allow list entries allow host, if any
+func testFQDN(w io.Writer, fqdn firewall.FQDN, host string) error {
+	entries := fqdn.Allowing(host)
+	if len(entries) == 0 {
+		_, err := fmt.Fprintf(w, "%s is not allowed: HTTP and TLS to it from %s are dropped\n", host, strings.Join(fqdn.HomeNetIPs, ", "))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s is allowed by %s\n", host, strings.Join(entries, ", "))
+	return err
+}
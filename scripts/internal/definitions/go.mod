@@ -0,0 +1,3 @@
+module modernisation-platform/definitions
+
+go 1.23
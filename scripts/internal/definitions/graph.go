@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"modernisation-platform/definitions/repo"
+	"modernisation-platform/definitions/topology"
+)
+
+func runGraph(args []string) error {
+	flags, repoRoot := newFlagSet("graph")
+	format := flags.String("format", "dot", "output format: dot or mermaid")
+	accounts := flags.Bool("accounts", false, "include the accounts in each subnet set")
+	ref := flags.String("ref", "", "read the definitions at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	graph, err := topology.Load(src)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "dot":
+		return graph.WriteDot(os.Stdout, *accounts)
+	case "mermaid":
+		return graph.WriteMermaid(os.Stdout, *accounts)
+	default:
+		return fmt.Errorf("graph: unknown format %q, expected dot or mermaid", *format)
+	}
+}
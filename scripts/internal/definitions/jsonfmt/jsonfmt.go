@@ -0,0 +1,304 @@
+// Package jsonfmt rewrites JSON documents into a canonical form: known keys in
+// a fixed order, laid out the way prettier lays out JSON, which MegaLinter
+// applies to the repository. Values are never changed, scalars are written as
+// they were read and array order is preserved.
+package jsonfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrintWidth is the line length prettier fits arrays and objects within
+const PrintWidth = 80
+
+// Schema describes the key order of an object. A schema applied to an array
+// applies to each of its elements.
+type Schema struct {
+	// Keys are written first, in this order. Any other keys follow in the order they were read.
+	Keys []string
+	// Fields holds the schema for the value of a key
+	Fields map[string]*Schema
+	// Map objects have arbitrary keys (e.g. subnet set names), which are sorted.
+	// Every value uses the Values schema.
+	Map    bool
+	Values *Schema
+}
+
+// object is a JSON object that remembers the order of its keys
+type object struct {
+	keys   []string
+	values map[string]any
+	// raw holds each key as it was written
+	raw map[string]string
+	// expanded is set if the object's first key was on a new line, which
+	// prettier keeps
+	expanded bool
+	// blank holds the keys followed by a blank line, which prettier keeps
+	blank map[string]bool
+}
+
+// array is a JSON array
+type array struct {
+	elements []any
+	// blank holds the indexes of elements followed by a blank line
+	blank map[int]bool
+}
+
+// scalar is a string, number, boolean or null as it was written
+type scalar string
+
+// Format returns the canonical form of a JSON document
+func Format(data []byte, schema *Schema) ([]byte, error) {
+	d := &decoder{data: data, json: json.NewDecoder(bytes.NewReader(data))}
+	d.json.UseNumber()
+
+	value, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.json.Token(); err != io.EOF {
+		return nil, errors.New("unexpected content after the JSON document")
+	}
+
+	var buf bytes.Buffer
+	encodeValue(&buf, value, schema, 0, 0, 0)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// decoder reads values along with the layout prettier keeps
+type decoder struct {
+	data []byte
+	json *json.Decoder
+}
+
+// token reads the next token, and returns its text and where it started
+func (d *decoder) token() (json.Token, string, int, error) {
+	before := int(d.json.InputOffset())
+	token, err := d.json.Token()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	after := int(d.json.InputOffset())
+	start := before + len(d.data[before:after]) - len(bytes.TrimLeft(d.data[before:after], " \t\r\n,:"))
+	return token, string(d.data[start:after]), start, nil
+}
+
+// blankBefore is whether there's a blank line between offset and the next token
+func (d *decoder) blankBefore(offset int) bool {
+	rest := d.data[offset:]
+	gap := rest[:len(rest)-len(bytes.TrimLeft(rest, " \t\r\n,"))]
+	return bytes.Count(gap, []byte("\n")) > 1
+}
+
+func (d *decoder) value() (any, error) {
+	token, text, start, err := d.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := &object{values: map[string]any{}, raw: map[string]string{}, blank: map[string]bool{}}
+		for d.json.More() {
+			keyToken, keyText, keyStart, err := d.token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string)
+			if len(obj.keys) == 0 {
+				obj.expanded = bytes.ContainsRune(d.data[start:keyStart], '\n')
+			}
+			value, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			if _, duplicate := obj.values[key]; duplicate {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			obj.keys = append(obj.keys, key)
+			obj.values[key] = value
+			obj.raw[key] = keyText
+			obj.blank[key] = d.json.More() && d.blankBefore(int(d.json.InputOffset()))
+		}
+		_, err := d.json.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := &array{elements: []any{}, blank: map[int]bool{}}
+		for d.json.More() {
+			value, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			arr.blank[len(arr.elements)] = d.json.More() && d.blankBefore(int(d.json.InputOffset()))
+			arr.elements = append(arr.elements, value)
+		}
+		_, err := d.json.Token()
+		return arr, err
+	default:
+		return scalar(text), nil
+	}
+}
+
+// orderedKeys returns the object's keys in canonical order
+func orderedKeys(obj *object, schema *Schema) []string {
+	if schema == nil {
+		return obj.keys
+	}
+	if schema.Map {
+		keys := append([]string{}, obj.keys...)
+		sort.Strings(keys)
+		return keys
+	}
+
+	keys := []string{}
+	known := map[string]bool{}
+	for _, key := range schema.Keys {
+		known[key] = true
+		if _, ok := obj.values[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range obj.keys {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func fieldSchema(schema *Schema, key string) *Schema {
+	if schema == nil {
+		return nil
+	}
+	if schema.Map {
+		return schema.Values
+	}
+	return schema.Fields[key]
+}
+
+// mustBreak is whether prettier always expands a value: an object whose first
+// key was on a new line, an array of two or more objects or arrays that each
+// have more than one entry, or anything containing one of those
+func mustBreak(value any) bool {
+	switch v := value.(type) {
+	case *object:
+		if v.expanded {
+			return true
+		}
+		for _, key := range v.keys {
+			if mustBreak(v.values[key]) {
+				return true
+			}
+		}
+	case *array:
+		if len(v.elements) > 1 && allMultiple(v.elements) {
+			return true
+		}
+		for _, element := range v.elements {
+			if mustBreak(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allMultiple is whether every element is an object with more than one key,
+// or every element an array with more than one element
+func allMultiple(elements []any) bool {
+	for _, element := range elements {
+		switch v := element.(type) {
+		case *object:
+			if _, first := elements[0].(*object); !first || len(v.keys) < 2 {
+				return false
+			}
+		case *array:
+			if _, first := elements[0].(*array); !first || len(v.elements) < 2 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// flat renders a value on one line, e.g. { "name": "app", "tags": ["a", "b"] }
+func flat(value any, schema *Schema) string {
+	switch v := value.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			return "{}"
+		}
+		parts := []string{}
+		for _, key := range orderedKeys(v, schema) {
+			parts = append(parts, v.raw[key]+": "+flat(v.values[key], fieldSchema(schema, key)))
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	case *array:
+		parts := []string{}
+		for _, element := range v.elements {
+			parts = append(parts, flat(element, schema))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return string(value.(scalar))
+}
+
+// encodeValue writes a value starting at column, on one line if it fits
+// within PrintWidth with the trailing characters that follow it on the line
+func encodeValue(buf *bytes.Buffer, value any, schema *Schema, depth, column, trailing int) {
+	line := flat(value, schema)
+	if _, ok := value.(scalar); ok || !mustBreak(value) && column+utf8.RuneCountInString(line)+trailing <= PrintWidth {
+		buf.WriteString(line)
+		return
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+	switch v := value.(type) {
+	case *object:
+		keys := orderedKeys(v, schema)
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + v.raw[key] + ": ")
+			encodeValue(buf, v.values[key], fieldSchema(schema, key), depth+1, len(indent)+utf8.RuneCountInString(v.raw[key])+2, separator(i, len(keys)))
+			writeSeparator(buf, i, len(keys), v.blank[key])
+		}
+		buf.WriteString(strings.Repeat("  ", depth) + "}")
+	case *array:
+		buf.WriteString("[\n")
+		for i, element := range v.elements {
+			buf.WriteString(indent)
+			encodeValue(buf, element, schema, depth+1, len(indent), separator(i, len(v.elements)))
+			writeSeparator(buf, i, len(v.elements), v.blank[i])
+		}
+		buf.WriteString(strings.Repeat("  ", depth) + "]")
+	}
+}
+
+// separator is the width of the comma after the i'th of n entries
+func separator(i, n int) int {
+	if i < n-1 {
+		return 1
+	}
+	return 0
+}
+
+// writeSeparator ends the i'th of n entries of an expanded array or object
+func writeSeparator(buf *bytes.Buffer, i, n int, blank bool) {
+	if i < n-1 {
+		buf.WriteByte(',')
+		if blank {
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteByte('\n')
+}
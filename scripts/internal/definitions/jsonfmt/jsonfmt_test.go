@@ -0,0 +1,99 @@
+package jsonfmt
+
+import (
+	"testing"
+)
+
+var schema = &Schema{
+	Keys: []string{"name", "environments", "tags"},
+	Fields: map[string]*Schema{
+		"environments": {Keys: []string{"name", "access"}},
+		"sets":         {Map: true, Values: &Schema{Keys: []string{"cidr", "accounts"}}},
+	},
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "orders known keys and keeps unknown keys after them",
+			input:    "{\n\"tags\": {}, \"extra\": 1, \"name\": \"app\", \"another\": true}",
+			expected: "{\n  \"name\": \"app\",\n  \"tags\": {},\n  \"extra\": 1,\n  \"another\": true\n}\n",
+		},
+		{
+			name:     "applies the schema to array elements and keeps array order",
+			input:    `{"environments": [{"access": [], "name": "test"}, {"name": "development"}]}`,
+			expected: "{\n  \"environments\": [{ \"name\": \"test\", \"access\": [] }, { \"name\": \"development\" }]\n}\n",
+		},
+		{
+			name:     "sorts map keys",
+			input:    "{\n\"sets\": {\"b\": {\"accounts\": [\"x\"], \"cidr\": \"10.0.0.0/8\"}, \"a\": {}}}",
+			expected: "{\n  \"sets\": { \"a\": {}, \"b\": { \"cidr\": \"10.0.0.0/8\", \"accounts\": [\"x\"] } }\n}\n",
+		},
+		{
+			name:     "keeps scalars as they were written",
+			input:    "{\n\"name\": \"A & B <team> \\u00e9\", \"size\": 1.50, \"nothing\": null}",
+			expected: "{\n  \"name\": \"A & B <team> \\u00e9\",\n  \"size\": 1.50,\n  \"nothing\": null\n}\n",
+		},
+		{
+			name:     "breaks lists and objects that don't fit in the print width",
+			input:    `{"name": "a-long-application-name", "environments": [{"name": "development"}], "tags": {"owner": "someone@example.com", "business-unit": "Platforms"}}`,
+			expected: "{\n  \"name\": \"a-long-application-name\",\n  \"environments\": [{ \"name\": \"development\" }],\n  \"tags\": { \"owner\": \"someone@example.com\", \"business-unit\": \"Platforms\" }\n}\n",
+		},
+		{
+			name:     "counts the trailing comma in the print width",
+			input:    "{\n\"name\": [\"aaaaaaaaaaaaaaaaaaaa\", \"bbbbbbbbbbbbbbbbbbbb\", \"cccccccccccccccccc\"], \"tags\": {}}",
+			expected: "{\n  \"name\": [\n    \"aaaaaaaaaaaaaaaaaaaa\",\n    \"bbbbbbbbbbbbbbbbbbbb\",\n    \"cccccccccccccccccc\"\n  ],\n  \"tags\": {}\n}\n",
+		},
+		{
+			name:     "keeps an object expanded if its first key was on a new line, and its parents with it",
+			input:    "{\"environments\": [{\n\"name\": \"test\"}]}",
+			expected: "{\n  \"environments\": [\n    {\n      \"name\": \"test\"\n    }\n  ]\n}\n",
+		},
+		{
+			name:     "expands a list of two or more objects with more than one key",
+			input:    `{"environments": [{"name": "test", "access": []}, {"name": "development", "access": []}]}`,
+			expected: "{\n  \"environments\": [\n    { \"name\": \"test\", \"access\": [] },\n    { \"name\": \"development\", \"access\": [] }\n  ]\n}\n",
+		},
+		{
+			name:     "keeps single blank lines between entries",
+			input:    "{\n\"name\": \"app\",\n\n\n\"tags\": {}\n}",
+			expected: "{\n  \"name\": \"app\",\n\n  \"tags\": {}\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatted, err := Format([]byte(test.input), schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(formatted) != test.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", formatted, test.expected)
+			}
+
+			again, err := Format(formatted, schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(formatted) {
+				t.Errorf("formatting is not idempotent, got:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatRejectsInvalidDocuments(t *testing.T) {
+	for _, input := range []string{
+		`{"name": "app",}`,
+		`{"name": "app"} {"name": "other"}`,
+		`{"name": "app", "name": "other"}`,
+	} {
+		if _, err := Format([]byte(input), schema); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
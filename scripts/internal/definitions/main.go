@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/repo"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"check-firewall":    {"check the network firewall rules resolve and are valid", runCheckFirewall},
+	"check-membership":  {"check environments against the network subnet set memberships", runCheckMembership},
+	"check-vpn":         {"check the site-to-site VPN attachments and list each VPN's remote network", runCheckVPN},
+	"diff":              {"compare the estate between two git refs", runDiff},
+	"endpoints":         {"report additional endpoints by business unit and tier, and the drift between tiers", runEndpoints},
+	"firewall-impact":   {"report the flows a change to the network firewall rules newly allows or blocks", runFirewallImpact},
+	"firewall-rules":    {"show the network firewall rules with their sets and ranges resolved", runFirewallRules},
+	"flow":              {"show whether the network firewall allows a flow, and the rule that decides it", runFlow},
+	"fmt":               {"rewrite definition files in canonical key order and indentation", runFmt},
+	"fqdn":              {"check the network firewall domain allow list, or test which entry allows a host", runFQDN},
+	"graph":             {"draw the hub-and-spoke network as Graphviz DOT or Mermaid", runGraph},
+	"new-application":   {"create an environment definition for a new application", runNewApplication},
+	"subnets":           {"show the per-AZ subnets of a subnet set and check additional cidrs", runSubnets},
+	"suricata":          {"render the network firewall rules as the Suricata rules AWS Network Firewall runs", runSuricata},
+	"validate-networks": {"check environments-networks definitions against each other and the environments", runValidateNetworks},
+	"whereis":           {"show the network, subnet set and cidr an account lives in", runWhereis},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: definitions <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun `definitions <command> -h` for the command's flags")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// newFlagSet creates the flags for a command, including the shared --repo-root flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	repoRoot := flags.String("repo-root", "", "path to the modernisation-platform repository, discovered from the working directory if not set")
+	return flags, repoRoot
+}
+
+// resolveRepoRoot returns root, or discovers it from the working directory if empty
+func resolveRepoRoot(root string) (string, error) {
+	if root != "" {
+		return root, nil
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return repo.FindRoot(workingDir)
+}
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,62 @@
+package networks
+
+import (
+	"sort"
+)
+
+// EndpointUsage is one business unit's use of an additional endpoint, by tier
+type EndpointUsage struct {
+	BusinessUnit string
+	Endpoint     string
+	Tiers        map[string]bool
+	// Drift is set when the endpoint is in lower tiers but not production, or the other way round
+	Drift string
+}
+
+// EndpointMatrix returns a row per business unit and endpoint in any of its
+// networks, sorted, along with the tiers that have at least one network.
+func EndpointMatrix(networks []Network) ([]EndpointUsage, []string) {
+	rows := map[[2]string]*EndpointUsage{}
+	production := map[string]bool{}
+	tiersUsed := map[string]bool{}
+	for _, network := range networks {
+		tiersUsed[network.Tier()] = true
+		if network.Tier() == "production" {
+			production[network.BusinessUnit()] = true
+		}
+		for _, endpoint := range network.Options.AdditionalEndpoints {
+			key := [2]string{network.BusinessUnit(), endpoint}
+			if rows[key] == nil {
+				rows[key] = &EndpointUsage{BusinessUnit: network.BusinessUnit(), Endpoint: endpoint, Tiers: map[string]bool{}}
+			}
+			rows[key].Tiers[network.Tier()] = true
+		}
+	}
+
+	matrix := make([]EndpointUsage, 0, len(rows))
+	for _, row := range rows {
+		lower := row.Tiers["development"] || row.Tiers["test"] || row.Tiers["preproduction"]
+		switch {
+		case !production[row.BusinessUnit]:
+		case lower && !row.Tiers["production"]:
+			row.Drift = "missing in production"
+		case !lower && row.Tiers["production"]:
+			row.Drift = "only in production"
+		}
+		matrix = append(matrix, *row)
+	}
+	sort.Slice(matrix, func(i, j int) bool {
+		if matrix[i].BusinessUnit != matrix[j].BusinessUnit {
+			return matrix[i].BusinessUnit < matrix[j].BusinessUnit
+		}
+		return matrix[i].Endpoint < matrix[j].Endpoint
+	})
+
+	tiers := []string{}
+	for _, tier := range Tiers {
+		if tiersUsed[tier] {
+			tiers = append(tiers, tier)
+		}
+	}
+	return matrix, tiers
+}
@@ -0,0 +1,37 @@
+package networks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func TestEndpointMatrix(t *testing.T) {
+	networks := load(t, repotest.Memory{
+		"environments-networks/hmpps-development.json": `{"cidr": {"subnet_sets": {}}, "options": {"additional_endpoints": ["com.amazonaws.eu-west-2.athena", "com.amazonaws.eu-west-2.glue"]}}`,
+		"environments-networks/hmpps-production.json":  `{"cidr": {"subnet_sets": {}}, "options": {"additional_endpoints": ["com.amazonaws.eu-west-2.athena", "com.amazonaws.eu-west-2.xray"]}}`,
+		"environments-networks/laa-development.json":   `{"cidr": {"subnet_sets": {}}, "options": {"additional_endpoints": ["com.amazonaws.eu-west-2.glue"]}}`,
+	})
+
+	matrix, tiers := EndpointMatrix(networks)
+	if strings.Join(tiers, ",") != "development,production" {
+		t.Errorf("got tiers %v, expected development and production", tiers)
+	}
+
+	got := []string{}
+	for _, row := range matrix {
+		got = append(got, fmt.Sprintf("%s %s dev=%t prod=%t %s", row.BusinessUnit, row.Endpoint, row.Tiers["development"], row.Tiers["production"], row.Drift))
+	}
+	expected := []string{
+		"hmpps com.amazonaws.eu-west-2.athena dev=true prod=true ",
+		"hmpps com.amazonaws.eu-west-2.glue dev=true prod=false missing in production",
+		"hmpps com.amazonaws.eu-west-2.xray dev=false prod=true only in production",
+		// laa has no production network to drift from
+		"laa com.amazonaws.eu-west-2.glue dev=true prod=false ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,77 @@
+package networks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/environments"
+)
+
+// CheckMembership joins the accounts in environments/*.json with the subnet
+// set memberships in environments-networks/*.json. It reports network entries
+// with no matching environment, member environments that aren't in exactly
+// one network, and accounts in a network for a different business unit
+// (sandbox networks are shared by every business unit).
+// Accounts with isolated-network set, and member-unrestricted accounts, have
+// their own VPC, so aren't required to be in a network.
+func CheckMembership(applications []environments.Application, networks []Network) []string {
+	// account -> network/subnet set
+	memberships := map[string][]string{}
+	// account -> networks, for the business unit check
+	memberOf := map[string][]Network{}
+	for _, network := range networks {
+		for _, name := range network.SubnetSetNames() {
+			for _, account := range network.Cidr.SubnetSets[name].Accounts {
+				memberships[account] = append(memberships[account], network.Name+"/"+name)
+			}
+		}
+		for account := range network.accounts() {
+			memberOf[account] = append(memberOf[account], network)
+		}
+	}
+
+	failures := []string{}
+	defined := map[string]bool{}
+	for _, app := range applications {
+		for _, account := range app.Accounts() {
+			name := account.Name()
+			defined[name] = true
+			locations := memberships[name]
+
+			switch {
+			case app.Isolated() || app.AccountType != "member":
+			case len(locations) == 0:
+				failures = append(failures, fmt.Sprintf("`%v` is a member environment that is not in any network", name))
+			case len(locations) > 1:
+				failures = append(failures, fmt.Sprintf("`%v` is in more than one network: %v", name, strings.Join(locations, ", ")))
+			}
+
+			for _, network := range memberOf[name] {
+				if !strings.EqualFold(network.BusinessUnit(), app.Tags.BusinessUnit) && network.Tier() != "sandbox" {
+					failures = append(failures, fmt.Sprintf("`%v` has business unit %v but is in the %v network", name, app.Tags.BusinessUnit, network.Name))
+				}
+			}
+		}
+	}
+
+	for account, locations := range memberships {
+		if !defined[account] {
+			failures = append(failures, fmt.Sprintf("`%v` is in %v but is not an environment in %v/*.json", account, strings.Join(locations, ", "), environments.Dir))
+		}
+	}
+
+	sort.Strings(failures)
+	return failures
+}
+
+// accounts returns the set of accounts across all of the network's subnet sets
+func (n Network) accounts() map[string]bool {
+	accounts := map[string]bool{}
+	for _, set := range n.Cidr.SubnetSets {
+		for _, account := range set.Accounts {
+			accounts[account] = true
+		}
+	}
+	return accounts
+}
@@ -0,0 +1,46 @@
+package networks
+
+import (
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func TestCheckMembership(t *testing.T) {
+	src := repotest.Memory{
+		"environments/alpha.json":     `{"account-type": "member", "tags": {"business-unit": "HMPPS"}, "environments": [{"name": "development"}, {"name": "test"}, {"name": "production"}]}`,
+		"environments/bravo.json":     `{"account-type": "member", "tags": {"business-unit": "LAA"}, "environments": [{"name": "development"}]}`,
+		"environments/isolated.json":  `{"account-type": "member", "isolated-network": "true", "tags": {"business-unit": "HQ"}, "environments": [{"name": "development"}, {"name": "test"}]}`,
+		"environments/core-vpc.json":  `{"account-type": "core", "tags": {"business-unit": "Platforms"}, "environments": [{"name": "development"}]}`,
+		"environments/sprinkler.json": `{"account-type": "member", "tags": {"business-unit": "Platforms"}, "environments": [{"name": "development"}]}`,
+		"environments-networks/hmpps-development.json": `{"cidr": {"subnet_sets": {
+			"general": {"cidr": "10.26.24.0/21", "accounts": ["alpha-development", "bravo-development", "removed-development"]},
+			"extra": {"cidr": "10.26.32.0/21", "accounts": ["alpha-development"]}
+		}}, "options": {}}`,
+		"environments-networks/hmpps-test.json":       `{"cidr": {"subnet_sets": {"general": {"cidr": "10.26.8.0/21", "accounts": ["isolated-test"]}}}, "options": {}}`,
+		"environments-networks/hmpps-production.json": `{"cidr": {"subnet_sets": {"general": {"cidr": "10.27.8.0/21", "accounts": ["alpha-production"]}}}, "options": {}}`,
+		"environments-networks/garden-sandbox.json":   `{"cidr": {"subnet_sets": {"general": {"cidr": "10.231.0.0/21", "accounts": ["sprinkler-development"]}}}, "options": {}}`,
+	}
+	applications, err := environments.Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	networks, err := Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"`alpha-development` is in more than one network: hmpps-development/extra, hmpps-development/general",
+		"`alpha-test` is a member environment that is not in any network",
+		"`bravo-development` has business unit LAA but is in the hmpps-development network",
+		"`isolated-test` has business unit HQ but is in the hmpps-test network",
+		"`removed-development` is in hmpps-development/general but is not an environment in environments/*.json",
+	}
+	failures := CheckMembership(applications, networks)
+	if strings.Join(failures, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(failures, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,136 @@
+// Package networks models the business unit networks in environments-networks/*.json.
+package networks
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/jsonfmt"
+	"modernisation-platform/definitions/repo"
+)
+
+// Dir is the location of the network definitions relative to the repository root
+const Dir = "environments-networks"
+
+// Definition is a single environments-networks/<business-unit>-<tier>.json file
+type Definition struct {
+	Cidr    Cidr       `json:"cidr"`
+	Options Options    `json:"options"`
+	Nacl    []NaclRule `json:"nacl,omitempty"`
+}
+
+type Cidr struct {
+	// TransitGateway and Protected are only used by the core VPCs
+	TransitGateway string               `json:"transit_gateway,omitempty"`
+	Protected      string               `json:"protected,omitempty"`
+	SubnetSets     map[string]SubnetSet `json:"subnet_sets"`
+}
+
+type SubnetSet struct {
+	Cidr     string   `json:"cidr"`
+	Accounts []string `json:"accounts"`
+}
+
+type Options struct {
+	BastionLinux bool `json:"bastion_linux"`
+	// AdditionalCidrs are external ranges, such as PSN, allowed through the VPC NACLs
+	AdditionalCidrs []string `json:"additional_cidrs"`
+	// AdditionalEndpoints are VPC endpoint service names, e.g. com.amazonaws.eu-west-2.athena
+	AdditionalEndpoints    []string `json:"additional_endpoints"`
+	AdditionalPrivateZones []string `json:"additional_private_zones"`
+	// AdditionalVpcs are the names of other platform VPCs allowed through the VPC NACLs
+	AdditionalVpcs []string `json:"additional_vpcs"`
+	DnsZoneExtend  []string `json:"dns_zone_extend"`
+}
+
+// NaclRule is an extra network ACL rule for one subnet type in a subnet set
+type NaclRule struct {
+	SubnetSet  string      `json:"subnet_set"`
+	SubnetType string      `json:"subnet_type"`
+	Egress     bool        `json:"egress"`
+	Protocol   string      `json:"protocol"`
+	RuleAction string      `json:"rule_action"`
+	RuleNumber json.Number `json:"rule_number"`
+	CidrBlock  string      `json:"cidr_block"`
+	FromPort   json.Number `json:"from_port"`
+	ToPort     json.Number `json:"to_port"`
+}
+
+// Network is a definition together with its name, taken from the file name
+type Network struct {
+	Name string
+	Definition
+}
+
+// Tiers are the core-vpc accounts a network can live in, taken from the file name suffix
+var Tiers = []string{"development", "test", "preproduction", "production", "sandbox"}
+
+// BusinessUnit returns the name without its tier, e.g. hmpps for hmpps-development
+func (n Network) BusinessUnit() string {
+	if i := strings.LastIndex(n.Name, "-"); i >= 0 {
+		return n.Name[:i]
+	}
+	return n.Name
+}
+
+// Tier returns the core-vpc tier, e.g. development for hmpps-development
+func (n Network) Tier() string {
+	if i := strings.LastIndex(n.Name, "-"); i >= 0 {
+		return n.Name[i+1:]
+	}
+	return ""
+}
+
+// SubnetSetNames returns the names of the network's subnet sets, sorted
+func (n Network) SubnetSetNames() []string {
+	names := make([]string, 0, len(n.Cidr.SubnetSets))
+	for name := range n.Cidr.SubnetSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load reads every network definition from src, sorted by name
+func Load(src repo.Source) ([]Network, error) {
+	files, err := src.ListFiles(Dir, ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]Network, 0, len(files))
+	for _, file := range files {
+		jsonData, err := src.ReadFile(Dir + "/" + file)
+		if err != nil {
+			return nil, err
+		}
+		var definition Definition
+		if err := json.Unmarshal(jsonData, &definition); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", Dir, file, err)
+		}
+		networks = append(networks, Network{
+			Name:       strings.TrimSuffix(file, ".json"),
+			Definition: definition,
+		})
+	}
+
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+	return networks, nil
+}
+
+// Schema is the canonical key order of a network definition
+var Schema = &jsonfmt.Schema{
+	Keys: []string{"cidr", "options", "nacl"},
+	Fields: map[string]*jsonfmt.Schema{
+		"cidr": {
+			Keys: []string{"transit_gateway", "protected", "subnet_sets"},
+			Fields: map[string]*jsonfmt.Schema{
+				"subnet_sets": {Map: true, Values: &jsonfmt.Schema{Keys: []string{"cidr", "accounts"}}},
+			},
+		},
+		"nacl":    {Keys: []string{"subnet_set", "egress", "subnet_type", "protocol", "rule_action", "rule_number", "cidr_block", "from_port", "to_port"}},
+		"options": {Keys: []string{"bastion_linux", "additional_cidrs", "additional_endpoints", "additional_private_zones", "additional_vpcs", "dns_zone_extend"}},
+	},
+}
@@ -0,0 +1,131 @@
+package networks
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// accountNamePattern mirrors policies/networking
+	accountNamePattern = regexp.MustCompile(`\S+(-development|-test|-preproduction|-production)`)
+	// endpointPattern matches VPC endpoint service names, e.g. com.amazonaws.eu-west-2.transfer.server
+	endpointPattern = regexp.MustCompile(`^com\.amazonaws\.[a-z]{2}(-gov)?-[a-z]+-[0-9]\.[a-z0-9-]+(\.[a-z0-9-]+)*$`)
+)
+
+func (n Network) filename() string {
+	return Dir + "/" + n.Name + ".json"
+}
+
+// Validate checks a set of networks against each other and against the
+// accounts defined in environments/*.json. It covers the shape checks in
+// policies/networking plus the cross-file checks the policies can't do, and
+// returns one message per failure.
+func Validate(networks []Network, accounts map[string]bool) []string {
+	failures := []string{}
+	for _, network := range networks {
+		failures = append(failures, network.validate(networks, accounts)...)
+	}
+	return append(failures, validateMembership(networks)...)
+}
+
+func (n Network) validate(networks []Network, accounts map[string]bool) []string {
+	failures := []string{}
+	fail := func(format string, args ...any) {
+		failures = append(failures, fmt.Sprintf("`%v` "+format, append([]any{n.filename()}, args...)...))
+	}
+
+	tierKnown := false
+	for _, tier := range Tiers {
+		tierKnown = tierKnown || n.Tier() == tier
+	}
+	if !tierKnown {
+		fail("does not end in a known tier, expected one of: %v", strings.Join(Tiers, ", "))
+	}
+
+	if n.Cidr.SubnetSets == nil {
+		fail("is missing the `subnet_sets` key")
+	}
+	if n.Options.AdditionalEndpoints == nil {
+		fail("is missing the `additional_endpoints` key")
+	}
+	if n.Options.DnsZoneExtend == nil {
+		fail("is missing the `dns_zone_extend` key")
+	}
+
+	for _, name := range n.SubnetSetNames() {
+		set := n.Cidr.SubnetSets[name]
+		if _, err := netip.ParsePrefix(set.Cidr); err != nil {
+			fail("subnet set `%v` has an invalid cidr: got `%v`", name, set.Cidr)
+		}
+		for _, account := range set.Accounts {
+			if !accountNamePattern.MatchString(account) {
+				fail("%v does not end include the environment name e.g. *-development|*-test|*-preproduction|*-production", account)
+			}
+			if !accounts[account] {
+				fail("subnet set `%v` lists `%v`, which is not an environment in environments/*.json", name, account)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, endpoint := range n.Options.AdditionalEndpoints {
+		if !endpointPattern.MatchString(endpoint) {
+			fail("has an invalid additional endpoint: got `%v`, expected com.amazonaws.<region>.<service>", endpoint)
+		}
+		if seen[endpoint] {
+			fail("lists the additional endpoint `%v` more than once", endpoint)
+		}
+		seen[endpoint] = true
+	}
+
+	for _, cidr := range n.Options.AdditionalCidrs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			fail("has an invalid additional cidr: got `%v`", cidr)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, network := range networks {
+		names[network.Name] = true
+	}
+	for _, vpc := range n.Options.AdditionalVpcs {
+		if !names[vpc] {
+			fail("has an additional vpc `%v` that is not defined in %v", vpc, Dir)
+		}
+	}
+
+	return failures
+}
+
+// validateMembership checks that no account is in more than one network (or
+// subnet set) within the same tier. It doesn't report accounts that are in
+// no network, as that needs the application's isolated-network setting;
+// CheckMembership covers the "exactly one" rule.
+func validateMembership(networks []Network) []string {
+	// tier -> account -> network/subnet set
+	memberships := map[string]map[string][]string{}
+	for _, network := range networks {
+		if memberships[network.Tier()] == nil {
+			memberships[network.Tier()] = map[string][]string{}
+		}
+		for _, name := range network.SubnetSetNames() {
+			for _, account := range network.Cidr.SubnetSets[name].Accounts {
+				memberships[network.Tier()][account] = append(memberships[network.Tier()][account], network.Name+"/"+name)
+			}
+		}
+	}
+
+	failures := []string{}
+	for tier, accounts := range memberships {
+		for account, locations := range accounts {
+			if len(locations) > 1 {
+				failures = append(failures, fmt.Sprintf("`%v` is in more than one %v network: %v", account, tier, strings.Join(locations, ", ")))
+			}
+		}
+	}
+	sort.Strings(failures)
+	return failures
+}
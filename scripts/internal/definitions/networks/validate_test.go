@@ -0,0 +1,108 @@
+package networks
+
+import (
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+var accounts = map[string]bool{
+	"alpha-development": true,
+	"bravo-development": true,
+	"alpha-production":  true,
+}
+
+func load(t *testing.T, files repotest.Memory) []Network {
+	t.Helper()
+	networks, err := Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return networks
+}
+
+func TestNetworkNameParts(t *testing.T) {
+	network := Network{Name: "hmpps-preproduction"}
+	if network.BusinessUnit() != "hmpps" || network.Tier() != "preproduction" {
+		t.Errorf("got %s and %s, expected hmpps and preproduction", network.BusinessUnit(), network.Tier())
+	}
+}
+
+func TestValidateAcceptsValidNetworks(t *testing.T) {
+	networks := load(t, repotest.Memory{
+		"environments-networks/hmpps-development.json": `{
+			"cidr": {"subnet_sets": {"general": {"cidr": "10.26.24.0/21", "accounts": ["alpha-development"]}}},
+			"options": {
+				"bastion_linux": true,
+				"additional_cidrs": ["51.0.0.0/8"],
+				"additional_endpoints": ["com.amazonaws.eu-west-2.athena", "com.amazonaws.eu-west-2.transfer.server"],
+				"additional_vpcs": ["laa-development"],
+				"dns_zone_extend": []
+			}
+		}`,
+		"environments-networks/laa-development.json": `{
+			"cidr": {"subnet_sets": {"general": {"cidr": "10.26.56.0/21", "accounts": ["bravo-development"]}}},
+			"options": {"additional_endpoints": [], "dns_zone_extend": []}
+		}`,
+	})
+
+	if failures := Validate(networks, accounts); len(failures) != 0 {
+		t.Errorf("expected no failures, got %v", failures)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	networks := load(t, repotest.Memory{
+		"environments-networks/hmpps-development.json": `{
+			"cidr": {"subnet_sets": {
+				"general": {"cidr": "10.26.24.0/21", "accounts": ["alpha-development", "missing-development", "alpha-staging"]},
+				"extra": {"cidr": "10.26.300.0/21", "accounts": ["alpha-development"]}
+			}},
+			"options": {
+				"additional_cidrs": ["not-a-cidr"],
+				"additional_endpoints": ["com.amazonaws.eu-west-2.athena", "com.amazonaws.eu-west-2.athena", "athena", "com.amazonaws.euwest2.s3"],
+				"additional_vpcs": ["cica-development"],
+				"dns_zone_extend": []
+			}
+		}`,
+		"environments-networks/laa-development.json": `{
+			"cidr": {"subnet_sets": {"general": {"cidr": "10.26.56.0/21", "accounts": ["alpha-development"]}}},
+			"options": {}
+		}`,
+		"environments-networks/laa-staging.json": `{
+			"cidr": {"subnet_sets": {}},
+			"options": {"additional_endpoints": [], "dns_zone_extend": []}
+		}`,
+	})
+
+	expected := []string{
+		"`environments-networks/hmpps-development.json` subnet set `extra` has an invalid cidr: got `10.26.300.0/21`",
+		"`environments-networks/hmpps-development.json` subnet set `general` lists `missing-development`, which is not an environment in environments/*.json",
+		"`environments-networks/hmpps-development.json` alpha-staging does not end include the environment name",
+		"`environments-networks/hmpps-development.json` subnet set `general` lists `alpha-staging`, which is not an environment in environments/*.json",
+		"`environments-networks/hmpps-development.json` lists the additional endpoint `com.amazonaws.eu-west-2.athena` more than once",
+		"`environments-networks/hmpps-development.json` has an invalid additional endpoint: got `athena`",
+		"`environments-networks/hmpps-development.json` has an invalid additional endpoint: got `com.amazonaws.euwest2.s3`",
+		"`environments-networks/hmpps-development.json` has an invalid additional cidr: got `not-a-cidr`",
+		"`environments-networks/hmpps-development.json` has an additional vpc `cica-development` that is not defined",
+		"`environments-networks/laa-development.json` is missing the `additional_endpoints` key",
+		"`environments-networks/laa-development.json` is missing the `dns_zone_extend` key",
+		"`environments-networks/laa-staging.json` does not end in a known tier",
+		"`alpha-development` is in more than one development network: hmpps-development/extra, hmpps-development/general, laa-development/general",
+	}
+
+	failures := Validate(networks, accounts)
+	for _, want := range expected {
+		found := false
+		for _, failure := range failures {
+			found = found || strings.HasPrefix(failure, want)
+		}
+		if !found {
+			t.Errorf("expected a failure starting %q", want)
+		}
+	}
+	if len(failures) != len(expected) {
+		t.Errorf("got %d failures, expected %d:\n%s", len(failures), len(expected), strings.Join(failures, "\n"))
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"modernisation-platform/definitions/environments"
+)
+
+// applicationOptions holds everything needed to build a new application definition
+type applicationOptions struct {
+	name                  string
+	accountType           string
+	businessUnit          string
+	infrastructureSupport string
+	owner                 string
+	slackChannel          string
+	goLiveDate            string
+	cni                   bool
+	// cniSet records that --critical-national-infrastructure was given, so
+	// an explicit false isn't prompted for again
+	cniSet       bool
+	environments []string
+	// access entries are group:level for every environment, or environment=group:level for one
+	access           []string
+	oidcRepositories []string
+}
+
+func runNewApplication(args []string) error {
+	var opts applicationOptions
+	var environmentList string
+
+	flags, repoRoot := newFlagSet("new-application")
+	flags.StringVar(&opts.name, "name", "", "application name, used for the file name and application tag")
+	flags.StringVar(&opts.accountType, "account-type", "member", "account type")
+	flags.StringVar(&opts.businessUnit, "business-unit", "", "business unit, one of: "+strings.Join(environments.AllowedBusinessUnits, ", "))
+	flags.StringVar(&opts.infrastructureSupport, "infrastructure-support", "", "email address of the team supporting the infrastructure")
+	flags.StringVar(&opts.owner, "owner", "", "owner tag, e.g. \"Team name: team@justice.gov.uk\"")
+	flags.StringVar(&opts.slackChannel, "slack-channel", "", "slack channel for the application (optional)")
+	flags.StringVar(&opts.goLiveDate, "go-live-date", "", "go-live date as YYYY-MM-DD (optional)")
+	flags.BoolVar(&opts.cni, "critical-national-infrastructure", false, "whether the application is critical national infrastructure")
+	flags.StringVar(&environmentList, "environments", "", "comma separated environments, e.g. development,production")
+	flags.Var((*stringList)(&opts.access), "access", "SSO group access as group:level for every environment, or environment=group:level (repeatable)")
+	flags.Var((*stringList)(&opts.oidcRepositories), "oidc-repository", "GitHub repository allowed to use OIDC, e.g. ministryofjustice/modernisation-platform-environments (repeatable)")
+	interactive := flags.Bool("interactive", false, "prompt for anything not given as a flag")
+	stdout := flags.Bool("stdout", false, "print the definition instead of writing it")
+	force := flags.Bool("force", false, "overwrite an existing definition")
+	flags.Parse(args)
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "critical-national-infrastructure" {
+			opts.cniSet = true
+		}
+	})
+
+	if environmentList != "" {
+		opts.environments = strings.Split(environmentList, ",")
+	}
+
+	if *interactive {
+		newPrompter(os.Stdin, os.Stdout).complete(&opts)
+	}
+
+	app, err := opts.build()
+	if err != nil {
+		return err
+	}
+
+	if failures := environments.Validate(app); len(failures) > 0 {
+		return fmt.Errorf("new-application: the definition does not pass the environment policies:\n  %s", strings.Join(failures, "\n  "))
+	}
+
+	content, err := app.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if *stdout {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, environments.Dir, app.Name+".json")
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("new-application: %s already exists, use --force to overwrite it", path)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s\n\nNext steps:\n", path)
+	fmt.Printf("  - add %q to the accounts in policies/environments/expected.rego\n", app.Name)
+	if app.AccountType == "member" {
+		fmt.Println("  - add the new accounts to a subnet set in environments-networks")
+	}
+	return nil
+}
+
+// build turns the options into an application definition
+func (o applicationOptions) build() (environments.Application, error) {
+	if o.name == "" {
+		return environments.Application{}, errors.New("new-application: --name is required")
+	}
+
+	app := environments.Application{
+		Name: o.name,
+		Definition: environments.Definition{
+			AccountType:  o.accountType,
+			Environments: []environments.Environment{},
+			Tags: environments.Tags{
+				Application:                    o.name,
+				BusinessUnit:                   o.businessUnit,
+				InfrastructureSupport:          o.infrastructureSupport,
+				Owner:                          o.owner,
+				SlackChannel:                   o.slackChannel,
+				CriticalNationalInfrastructure: o.cni,
+			},
+			GithubOidcTeamRepositories: append([]string{}, o.oidcRepositories...),
+			GoLiveDate:                 o.goLiveDate,
+		},
+	}
+
+	for _, name := range o.environments {
+		app.Environments = append(app.Environments, environments.Environment{
+			Name:   strings.TrimSpace(name),
+			Access: []environments.Access{},
+		})
+	}
+
+	for _, entry := range o.access {
+		scope := ""
+		if i := strings.Index(entry, "="); i >= 0 {
+			scope, entry = entry[:i], entry[i+1:]
+		}
+		group, level, ok := strings.Cut(entry, ":")
+		if !ok || group == "" || level == "" {
+			return app, fmt.Errorf("new-application: invalid access %q, expected group:level or environment=group:level", entry)
+		}
+
+		matched := false
+		for i := range app.Environments {
+			if scope != "" && app.Environments[i].Name != scope {
+				continue
+			}
+			matched = true
+			app.Environments[i].Access = append(app.Environments[i].Access, environments.Access{SsoGroupName: group, Level: level})
+		}
+		if !matched {
+			return app, fmt.Errorf("new-application: access %q is for environment %q, which is not in --environments", entry, scope)
+		}
+	}
+
+	return app, nil
+}
+
+// prompter asks for values on the terminal
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) prompter {
+	return prompter{in: bufio.NewReader(in), out: out}
+}
+
+// ask prompts for a single value, returning def if nothing is entered
+func (p prompter) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, _ := p.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// choose prompts until one of options is entered. It gives up and returns
+// the last answer at the end of the input, leaving validation to report it.
+func (p prompter) choose(question string, options []string, def string) string {
+	for {
+		answer := p.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		for _, option := range options {
+			if answer == option {
+				return answer
+			}
+		}
+		fmt.Fprintf(p.out, "%q is not one of the allowed values\n", answer)
+		if _, err := p.in.Peek(1); err != nil {
+			return answer
+		}
+	}
+}
+
+// list prompts for a comma separated list of values
+func (p prompter) list(question string) []string {
+	values := []string{}
+	for _, value := range strings.Split(p.ask(question+" (comma separated)", ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// complete prompts for any options that weren't given as flags
+func (p prompter) complete(o *applicationOptions) {
+	if o.name == "" {
+		o.name = p.ask("Application name", "")
+	}
+	if o.businessUnit == "" {
+		o.businessUnit = p.choose("Business unit", environments.AllowedBusinessUnits, "")
+	}
+	if len(o.environments) == 0 {
+		o.environments = p.list("Environments, from " + strings.Join(environments.AllowedEnvironments, ", "))
+	}
+	if len(o.access) == 0 {
+		for _, env := range o.environments {
+			for _, group := range p.list("SSO groups for " + env) {
+				level := p.choose(fmt.Sprintf("Access level for %s in %s", group, env), environments.AllowedAccess, "developer")
+				o.access = append(o.access, fmt.Sprintf("%s=%s:%s", env, group, level))
+			}
+		}
+	}
+	if o.infrastructureSupport == "" {
+		o.infrastructureSupport = p.ask("Infrastructure support email", "")
+	}
+	if o.owner == "" {
+		o.owner = p.ask("Owner", "")
+	}
+	if o.slackChannel == "" {
+		o.slackChannel = p.ask("Slack channel (optional)", "")
+	}
+	if o.goLiveDate == "" {
+		o.goLiveDate = p.ask("Go-live date as YYYY-MM-DD (optional)", "")
+	}
+	if !o.cniSet {
+		o.cni = p.choose("Critical national infrastructure", []string{"true", "false"}, "false") == "true"
+	}
+	if len(o.oidcRepositories) == 0 {
+		o.oidcRepositories = p.list("GitHub repositories allowed to use OIDC")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/jsonfmt"
+)
+
+func TestBuildApplication(t *testing.T) {
+	opts := applicationOptions{
+		name:                  "new-app",
+		accountType:           "member",
+		businessUnit:          "HMPPS",
+		infrastructureSupport: "team@justice.gov.uk",
+		owner:                 "Team: team@justice.gov.uk",
+		environments:          []string{"development", "production"},
+		access:                []string{"new-app-team:developer", "production=new-app-team:read-only"},
+		oidcRepositories:      []string{"ministryofjustice/modernisation-platform-environments"},
+	}
+
+	app, err := opts.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := environments.Validate(app); len(failures) != 0 {
+		t.Fatalf("expected a valid definition, got %v", failures)
+	}
+
+	if len(app.Environments[0].Access) != 1 {
+		t.Errorf("development: got %d access entries, expected 1", len(app.Environments[0].Access))
+	}
+	if len(app.Environments[1].Access) != 2 || app.Environments[1].Access[1].Level != "read-only" {
+		t.Errorf("production: got %+v, expected developer and read-only", app.Environments[1].Access)
+	}
+
+	content, err := app.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted, err := jsonfmt.Format(content, environments.Schema); err != nil || string(formatted) != string(content) {
+		t.Errorf("expected the definition to already be in canonical form, got:\n%s", content)
+	}
+	for _, expected := range []string{
+		"{\n  \"account-type\": \"member\",\n  \"environments\": [",
+		"\"github-oidc-team-repositories\": [\n    \"ministryofjustice/modernisation-platform-environments\"\n  ],\n  \"go-live-date\": \"\"\n}\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected the definition to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestBuildApplicationRejectsAccessForUnknownEnvironment(t *testing.T) {
+	opts := applicationOptions{name: "new-app", environments: []string{"development"}, access: []string{"production=team:developer"}}
+	if _, err := opts.build(); err == nil {
+		t.Error("expected an error for access to an environment that isn't defined")
+	}
+}
+
+func TestPrompterCompletesMissingOptions(t *testing.T) {
+	input := strings.Join([]string{
+		"prompted-app",       // name
+		"Unknown",            // business unit, rejected
+		"LAA",                // business unit
+		"development, test",  // environments
+		"laa-team",           // development SSO groups
+		"",                   // access level, default developer
+		"laa-team",           // test SSO groups
+		"read-only",          // access level
+		"laa@justice.gov.uk", // infrastructure support
+		"LAA: laa@justice.gov.uk",
+		"",           // slack channel
+		"2025-09-01", // go-live date
+		"",           // critical national infrastructure, default false
+		"",           // oidc repositories
+	}, "\n") + "\n"
+
+	opts := applicationOptions{accountType: "member"}
+	newPrompter(strings.NewReader(input), &bytes.Buffer{}).complete(&opts)
+
+	app, err := opts.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := environments.Validate(app); len(failures) != 0 {
+		t.Fatalf("expected a valid definition, got %v", failures)
+	}
+	if app.Tags.BusinessUnit != "LAA" || app.GoLiveDate != "2025-09-01" || len(app.Environments) != 2 {
+		t.Errorf("prompted values were not applied: %+v", app)
+	}
+	if app.Environments[1].Access[0].Level != "read-only" {
+		t.Errorf("got %+v for test access, expected read-only", app.Environments[1].Access)
+	}
+}
+
+func TestPrompterSkipsOptionsGivenAsFlags(t *testing.T) {
+	opts := applicationOptions{
+		name:                  "flagged-app",
+		businessUnit:          "LAA",
+		environments:          []string{"development"},
+		access:                []string{"laa-team:developer"},
+		infrastructureSupport: "laa@justice.gov.uk",
+		owner:                 "LAA: laa@justice.gov.uk",
+		slackChannel:          "laa",
+		goLiveDate:            "2025-09-01",
+		cniSet:                true,
+		oidcRepositories:      []string{"ministryofjustice/laa"},
+	}
+	var out bytes.Buffer
+	newPrompter(strings.NewReader(""), &out).complete(&opts)
+
+	if out.Len() != 0 {
+		t.Errorf("expected no prompts when every option was given, got %q", out.String())
+	}
+	if opts.cni {
+		t.Error("expected --critical-national-infrastructure=false to be kept")
+	}
+}
@@ -0,0 +1,238 @@
+// Package repo reads files from the modernisation-platform repository, either
+// from the working tree or from a git ref without checking it out.
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Source reads files by their slash-separated path relative to the repository root
+type Source interface {
+	ReadFile(name string) ([]byte, error)
+	// ListFiles returns the names of the files in dir ending in suffix, sorted
+	ListFiles(dir, suffix string) ([]string, error)
+	// String describes the source, e.g. "working tree" or "main"
+	String() string
+}
+
+// FindRoot walks up from start until it finds the directory containing .git
+func FindRoot(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("could not find the repository root, use --repo-root")
+		}
+		dir = parent
+	}
+}
+
+// Open returns a Source for ref, or for the working tree if ref is empty. It
+// fails if ref doesn't name a commit.
+func Open(root, ref string) (Source, error) {
+	if ref == "" {
+		return WorkTree{Root: root}, nil
+	}
+	return OpenRef(root, ref)
+}
+
+// WorkTree reads files from disk
+type WorkTree struct {
+	Root string
+}
+
+func (w WorkTree) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(w.Root, filepath.FromSlash(name)))
+}
+
+func (w WorkTree) ListFiles(dir, suffix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(w.Root, filepath.FromSlash(dir)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (w WorkTree) String() string {
+	return "working tree"
+}
+
+// GitRef reads files as they were at a git ref. The ref is resolved and its
+// tree listed once, when it's opened, and file contents are read through a
+// single git cat-file process, started on the first read and stopped by Close.
+type GitRef struct {
+	Root string
+	Ref  string
+	// commit is the commit Ref resolved to when it was opened
+	commit string
+	// blobs are the object IDs of the files in the commit's tree, by path
+	blobs map[string]string
+	batch *catFile
+}
+
+// OpenRef returns a GitRef for ref, failing if it doesn't name a commit
+func OpenRef(root, ref string) (GitRef, error) {
+	g := GitRef{Root: root, Ref: ref, blobs: map[string]string{}, batch: &catFile{root: root}}
+	commit, err := git(root, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return GitRef{}, fmt.Errorf("%s is not a commit: %w", ref, err)
+	}
+	g.commit = strings.TrimSpace(string(commit))
+
+	tree, err := git(root, "ls-tree", "-r", "-z", "--full-tree", g.commit)
+	if err != nil {
+		return GitRef{}, err
+	}
+	for _, entry := range strings.Split(string(tree), "\x00") {
+		// <mode> SP <type> SP <object> TAB <path>
+		info, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if ok && len(fields) == 3 && fields[1] == "blob" {
+			g.blobs[name] = fields[2]
+		}
+	}
+	return g, nil
+}
+
+func (g GitRef) ReadFile(name string) ([]byte, error) {
+	if g.batch == nil {
+		return nil, fmt.Errorf("%s was not opened with OpenRef", g.Ref)
+	}
+	object, ok := g.blobs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s at %s: %w", name, g.Ref, fs.ErrNotExist)
+	}
+	return g.batch.read(object)
+}
+
+func (g GitRef) ListFiles(dir, suffix string) ([]string, error) {
+	if g.batch == nil {
+		return nil, fmt.Errorf("%s was not opened with OpenRef", g.Ref)
+	}
+	names := []string{}
+	for name := range g.blobs {
+		if path.Dir(name) == path.Clean(dir) && strings.HasSuffix(name, suffix) {
+			names = append(names, path.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (g GitRef) String() string {
+	return g.Ref
+}
+
+// Close stops the cat-file process, if a file has been read
+func (g GitRef) Close() error {
+	if g.batch == nil {
+		return nil
+	}
+	return g.batch.close()
+}
+
+// catFile reads objects through `git cat-file --batch`
+type catFile struct {
+	root string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func (c *catFile) read(object string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		cmd := exec.Command("git", "-C", c.root, "cat-file", "--batch")
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		c.cmd, c.stdin, c.stdout = cmd, stdin, bufio.NewReader(stdout)
+	}
+
+	if _, err := fmt.Fprintln(c.stdin, object); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	// <object> SP <type> SP <size> LF <contents> LF, or <object> SP missing LF
+	header, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+	}
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(c.stdout, content); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return content[:size], nil
+}
+
+func (c *catFile) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		return nil
+	}
+	c.stdin.Close()
+	err := c.cmd.Wait()
+	c.cmd = nil
+	return err
+}
+
+func git(root string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
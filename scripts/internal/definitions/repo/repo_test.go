@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "scripts", "internal", "definitions")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindRoot(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != root {
+		t.Errorf("got %s, expected %s", found, root)
+	}
+}
+
+func TestGitRefReadsCommittedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("environments/bravo.json", "committed")
+	write("environments/alpha.json", "committed")
+	write("environments/README.md", "not a definition")
+	write("environments/nested/charlie.json", "nested")
+	write("environments/empty.json", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	write("environments/alpha.json", "uncommitted")
+
+	ref, err := OpenRef(root, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	names, err := ref.ListFiles("environments", ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "alpha.json,bravo.json,empty.json" {
+		t.Errorf("got %v, expected alpha.json, bravo.json and empty.json", names)
+	}
+
+	// every read goes through the same cat-file process
+	for name, expected := range map[string]string{
+		"environments/alpha.json":          "committed",
+		"environments/bravo.json":          "committed",
+		"environments/empty.json":          "",
+		"environments/nested/charlie.json": "nested",
+	} {
+		content, err := ref.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("got %q for %s from the ref, expected %q", content, name, expected)
+		}
+	}
+
+	if _, err := ref.ReadFile("collaborators.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v for a missing file, expected fs.ErrNotExist", err)
+	}
+
+	if names, err := ref.ListFiles("environments-networks", ".json"); err != nil || len(names) != 0 {
+		t.Errorf("got %v, %v for a missing directory, expected no files", names, err)
+	}
+
+	if _, err := Open(root, "no-such-ref"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v for a ref that doesn't exist, expected a git error", err)
+	}
+
+	if err := ref.Close(); err != nil {
+		t.Errorf("closing the ref: %v", err)
+	}
+
+	worktree, err := Open(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := worktree.ReadFile("environments/alpha.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "uncommitted" {
+		t.Errorf("got %q from the working tree, expected the uncommitted content", content)
+	}
+}
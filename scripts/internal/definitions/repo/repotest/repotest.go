@@ -0,0 +1,36 @@
+// Package repotest provides an in-memory repo.Source for tests.
+package repotest
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Memory is an in-memory repo.Source keyed by path
+type Memory map[string]string
+
+func (m Memory) ReadFile(name string) ([]byte, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return []byte(content), nil
+}
+
+func (m Memory) ListFiles(dir, suffix string) ([]string, error) {
+	names := []string{}
+	for name := range m {
+		if path.Dir(name) == dir && strings.HasSuffix(name, suffix) {
+			names = append(names, path.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m Memory) String() string {
+	return "memory"
+}
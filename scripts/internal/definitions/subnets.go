@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+	"modernisation-platform/definitions/subnets"
+)
+
+func runSubnets(args []string) error {
+	flags, repoRoot := newFlagSet("subnets")
+	network := flags.String("network", "", "network to show, e.g. hmpps-development (required)")
+	subnetSet := flags.String("subnet-set", "general", "subnet set to show")
+	ref := flags.String("ref", "", "read the definitions at a git ref instead of the working tree")
+	var planned stringList
+	flags.Var(&planned, "additional-cidr", "planned additional cidr to check alongside the ones in the definition (repeatable)")
+	flags.Parse(args)
+
+	if *network == "" {
+		return fmt.Errorf("subnets: --network is required")
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+
+	var selected *networks.Network
+	for i := range loaded {
+		if loaded[i].Name == *network {
+			selected = &loaded[i]
+		}
+	}
+	if selected == nil {
+		return fmt.Errorf("subnets: no network definition %s/%s.json", networks.Dir, *network)
+	}
+	set, ok := selected.Cidr.SubnetSets[*subnetSet]
+	if !ok {
+		return fmt.Errorf("subnets: %s has no subnet set %q, expected one of: %v", *network, *subnetSet, selected.SubnetSetNames())
+	}
+
+	layout, err := subnets.Member.Split(set.Cidr)
+	if err != nil {
+		return fmt.Errorf("subnets: %s/%s: %w", *network, *subnetSet, err)
+	}
+	fmt.Printf("%s/%s %s\n", *network, *subnetSet, set.Cidr)
+	for _, subnet := range layout {
+		fmt.Printf("  %-20s %s\n", subnet.Name(), subnet.Cidr)
+	}
+
+	collisions := additionalCidrCollisions(*selected, loaded, planned)
+	for _, collision := range collisions {
+		fmt.Println(collision)
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("subnets: %d additional cidr collision(s)", len(collisions))
+	}
+	return nil
+}
+
+// additionalCidrCollisions checks network's additional cidrs, plus any planned
+// ones, against each other and against every subnet set in the estate.
+// Additional cidrs are external ranges, so one that overlaps a platform VPC
+// would route that VPC's traffic the wrong way.
+func additionalCidrCollisions(network networks.Network, all []networks.Network, planned []string) []string {
+	failures := []string{}
+
+	type block struct {
+		name   string
+		prefix netip.Prefix
+	}
+	sets := []block{}
+	for _, other := range all {
+		for _, name := range other.SubnetSetNames() {
+			if prefix, err := netip.ParsePrefix(other.Cidr.SubnetSets[name].Cidr); err == nil {
+				sets = append(sets, block{other.Name + "/" + name, prefix})
+			}
+		}
+	}
+
+	additional := []block{}
+	for _, cidr := range append(append([]string{}, network.Options.AdditionalCidrs...), planned...) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("additional cidr `%v` is not a valid cidr", cidr))
+			continue
+		}
+		for _, set := range sets {
+			if prefix.Overlaps(set.prefix) {
+				failures = append(failures, fmt.Sprintf("additional cidr `%v` overlaps subnet set %v (%v)", cidr, set.name, set.prefix))
+			}
+		}
+		for _, previous := range additional {
+			if prefix.Overlaps(previous.prefix) {
+				failures = append(failures, fmt.Sprintf("additional cidr `%v` overlaps additional cidr `%v`", cidr, previous.name))
+			}
+		}
+		additional = append(additional, block{cidr, prefix})
+	}
+	return failures
+}
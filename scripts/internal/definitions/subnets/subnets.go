@@ -0,0 +1,118 @@
+// Package subnets works out the per-AZ subnets the VPC modules carve out of a CIDR block.
+package subnets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+// AvailabilityZones are the sorted zones the VPC modules spread each subnet type over
+var AvailabilityZones = []string{"eu-west-2a", "eu-west-2b", "eu-west-2c"}
+
+// Layout describes how a VPC module splits a CIDR block: Newbits returns the
+// cidrsubnets arguments for a block, and each type takes the next three
+// subnets in order, one per availability zone.
+type Layout struct {
+	Types   []string
+	Newbits func(prefix netip.Prefix) []int
+}
+
+// Hub mirrors terraform/modules/vpc-hub, used by the core VPCs
+var Hub = Layout{
+	Types: []string{"transit-gateway", "data", "private", "public"},
+	Newbits: func(prefix netip.Prefix) []int {
+		if prefix.Bits() == 20 {
+			return []int{8, 8, 8, 4, 4, 4, 4, 4, 4, 4, 4, 4}
+		}
+		return []int{9, 9, 9, 4, 4, 4, 4, 4, 4, 4, 4, 4}
+	},
+}
+
+// Member mirrors the subnet set layout of the modernisation-platform-terraform-member-vpc
+// module used by core-vpc, which gives a /21 subnet set /24 private and data
+// subnets and /26 public subnets. Transit gateway subnets are created once per
+// VPC rather than per subnet set.
+var Member = Layout{
+	Types: []string{"private", "data", "public"},
+	Newbits: func(prefix netip.Prefix) []int {
+		return []int{3, 3, 3, 3, 3, 3, 5, 5, 5}
+	},
+}
+
+// Subnet is a single subnet in a layout, named <type>-<availability zone> as in the VPC modules
+type Subnet struct {
+	Type string
+	AZ   string
+	Cidr netip.Prefix
+}
+
+func (s Subnet) Name() string {
+	return s.Type + "-" + s.AZ
+}
+
+// Split returns the subnets layout carves out of cidr
+func (l Layout) Split(cidr string) ([]Subnet, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, err
+	}
+	cidrs, err := CidrSubnets(prefix, l.Newbits(prefix)...)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := len(AvailabilityZones)
+	if len(cidrs) != len(l.Types)*zones {
+		return nil, fmt.Errorf("layout produced %d subnets for %d types", len(cidrs), len(l.Types))
+	}
+	subnets := make([]Subnet, 0, len(cidrs))
+	for i, subnet := range cidrs {
+		subnets = append(subnets, Subnet{Type: l.Types[i/zones], AZ: AvailabilityZones[i%zones], Cidr: subnet})
+	}
+	return subnets, nil
+}
+
+// CidrSubnets behaves like the Terraform cidrsubnets function: each subnet
+// extends the prefix by its newbits and is allocated after the previous one,
+// aligned to its own size.
+func CidrSubnets(prefix netip.Prefix, newbits ...int) ([]netip.Prefix, error) {
+	if !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("%v: only IPv4 prefixes are supported", prefix)
+	}
+	prefix = prefix.Masked()
+	base := toUint(prefix.Addr())
+	end := uint64(base) + size(prefix.Bits())
+
+	next := uint64(base)
+	subnets := make([]netip.Prefix, 0, len(newbits))
+	for _, bits := range newbits {
+		length := prefix.Bits() + bits
+		if bits < 1 || length > 32 {
+			return nil, fmt.Errorf("%v: cannot add %d bits to the prefix", prefix, bits)
+		}
+		step := size(length)
+		start := (next + step - 1) / step * step
+		if start+step > end {
+			return nil, fmt.Errorf("%v: not enough space for a /%d", prefix, length)
+		}
+		subnets = append(subnets, netip.PrefixFrom(fromUint(uint32(start)), length))
+		next = start + step
+	}
+	return subnets, nil
+}
+
+func size(bits int) uint64 {
+	return 1 << (32 - bits)
+}
+
+func toUint(addr netip.Addr) uint32 {
+	b := addr.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func fromUint(value uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], value)
+	return netip.AddrFrom4(b)
+}
@@ -0,0 +1,91 @@
+package subnets
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestCidrSubnets(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		newbits  []int
+		expected []string
+	}{
+		{"10.1.0.0/16", []int{4, 4, 8, 4}, []string{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24", "10.1.48.0/20"}},
+		{"10.26.24.0/21", []int{3, 5, 3}, []string{"10.26.24.0/24", "10.26.25.0/26", "10.26.26.0/24"}},
+	}
+
+	for _, test := range tests {
+		subnets, err := CidrSubnets(netip.MustParsePrefix(test.prefix), test.newbits...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subnets) != len(test.expected) {
+			t.Fatalf("%s: got %v, expected %v", test.prefix, subnets, test.expected)
+		}
+		for i, subnet := range subnets {
+			if subnet.String() != test.expected[i] {
+				t.Errorf("%s: got %v, expected %v", test.prefix, subnets, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestCidrSubnetsRejectsOverflow(t *testing.T) {
+	if _, err := CidrSubnets(netip.MustParsePrefix("10.0.0.0/24"), 1, 1, 1); err == nil {
+		t.Error("expected an error when the subnets don't fit")
+	}
+	if _, err := CidrSubnets(netip.MustParsePrefix("10.0.0.0/30"), 4); err == nil {
+		t.Error("expected an error for a prefix longer than /32")
+	}
+}
+
+func TestHubLayout(t *testing.T) {
+	// matches the sample output in terraform/modules/vpc-hub/main.tf
+	subnets, err := Hub.Split("10.1.128.0/19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"transit-gateway-eu-west-2a": "10.1.128.0/28",
+		"data-eu-west-2a":            "10.1.130.0/23",
+		"data-eu-west-2c":            "10.1.134.0/23",
+		"private-eu-west-2a":         "10.1.136.0/23",
+		"public-eu-west-2c":          "10.1.146.0/23",
+	}
+	for _, subnet := range subnets {
+		if cidr, ok := expected[subnet.Name()]; ok && subnet.Cidr.String() != cidr {
+			t.Errorf("%s: got %v, expected %s", subnet.Name(), subnet.Cidr, cidr)
+		}
+	}
+	if len(subnets) != 12 {
+		t.Errorf("got %d subnets, expected 12", len(subnets))
+	}
+}
+
+func TestMemberLayout(t *testing.T) {
+	subnets, err := Member.Split("10.26.24.0/21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"private-eu-west-2a 10.26.24.0/24",
+		"private-eu-west-2b 10.26.25.0/24",
+		"private-eu-west-2c 10.26.26.0/24",
+		"data-eu-west-2a 10.26.27.0/24",
+		"data-eu-west-2b 10.26.28.0/24",
+		"data-eu-west-2c 10.26.29.0/24",
+		"public-eu-west-2a 10.26.30.0/26",
+		"public-eu-west-2b 10.26.30.64/26",
+		"public-eu-west-2c 10.26.30.128/26",
+	}
+	if len(subnets) != len(expected) {
+		t.Fatalf("got %d subnets, expected %d", len(subnets), len(expected))
+	}
+	for i, subnet := range subnets {
+		if got := subnet.Name() + " " + subnet.Cidr.String(); got != expected[i] {
+			t.Errorf("got %s, expected %s", got, expected[i])
+		}
+	}
+}
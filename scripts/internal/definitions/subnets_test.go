@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/networks"
+)
+
+func TestAdditionalCidrCollisions(t *testing.T) {
+	network := networks.Network{Name: "hmpps-development"}
+	network.Cidr.SubnetSets = map[string]networks.SubnetSet{"general": {Cidr: "10.26.24.0/21"}}
+	network.Options.AdditionalCidrs = []string{"51.0.0.0/8", "10.26.30.0/24"}
+	other := networks.Network{Name: "laa-development"}
+	other.Cidr.SubnetSets = map[string]networks.SubnetSet{"general": {Cidr: "10.26.56.0/21"}}
+
+	failures := additionalCidrCollisions(network, []networks.Network{network, other}, []string{"51.1.0.0/16", "10.26.60.0/22", "bad"})
+	expected := []string{
+		"additional cidr `10.26.30.0/24` overlaps subnet set hmpps-development/general (10.26.24.0/21)",
+		"additional cidr `51.1.0.0/16` overlaps additional cidr `51.0.0.0/8`",
+		"additional cidr `10.26.60.0/22` overlaps subnet set laa-development/general (10.26.56.0/21)",
+		"additional cidr `bad` is not a valid cidr",
+	}
+	if strings.Join(failures, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(failures, "\n"), strings.Join(expected, "\n"))
+	}
+
+	network.Options.AdditionalCidrs = []string{"51.0.0.0/8"}
+	if failures := additionalCidrCollisions(network, []networks.Network{network, other}, nil); len(failures) != 0 {
+		t.Errorf("expected no collisions, got %v", failures)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+func runSuricata(args []string) error {
+	flags, repoRoot := newFlagSet("suricata")
+	policyName := flags.String("policy", "", "only render one policy: external or inline")
+	out := flags.String("out", "", "write <policy>.rules files to this directory instead of stdout")
+	ref := flags.String("ref", "", "read the rules at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	rules, err := firewall.Load(src)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, policy := range firewall.Policies {
+		if *policyName != "" && policy.Name != *policyName {
+			continue
+		}
+		found = true
+		if *out == "" {
+			if err := rules.WriteSuricata(os.Stdout, policy); err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Create(filepath.Join(*out, policy.Name+".rules"))
+		if err != nil {
+			return err
+		}
+		if err := rules.WriteSuricata(file, policy); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("suricata: unknown policy %q", *policyName)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package topology models the platform's hub-and-spoke network: the core VPCs
+// and member networks attached to the transit gateway, split into the
+// live_data and non_live_data routing domains.
+package topology
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// Domains are the transit gateway routing domains. Traffic between VPCs in a
+// domain, and out of it, goes through that domain's inspection VPC.
+var Domains = []string{"live_data", "non_live_data"}
+
+// CoreAccounts are the core accounts with a live_data and non_live_data VPC,
+// defined in the locals at the top of terraform/environments/<account>/vpc.tf
+var CoreAccounts = []string{"core-logging", "core-network-services", "core-security", "core-shared-services"}
+
+// InspectionAccount holds the inspection VPCs rather than hub VPCs
+const InspectionAccount = "core-network-services"
+
+// coreCidrPattern matches e.g. `live_data     = "10.20.128.0/19"`
+var coreCidrPattern = regexp.MustCompile(`(?m)^\s*(live_data|non_live_data)\s*=\s*"([^"]+)"`)
+
+// Domain returns the routing domain for a network tier, matching is-live_data in core-vpc
+func Domain(tier string) string {
+	if tier == "production" || tier == "preproduction" {
+		return "live_data"
+	}
+	return "non_live_data"
+}
+
+// CoreVPC is a VPC in one of the core accounts
+type CoreVPC struct {
+	Account string
+	Domain  string
+	Cidr    string
+}
+
+func (c CoreVPC) Name() string {
+	return c.Account + "-" + c.Domain
+}
+
+// SubnetSet is a member subnet set and the accounts that share it
+type SubnetSet struct {
+	Network  string
+	Name     string
+	Cidr     string
+	Domain   string
+	Accounts []string
+}
+
+// Graph is the whole topology
+type Graph struct {
+	Core       []CoreVPC
+	SubnetSets []SubnetSet
+}
+
+// LoadCore reads the core VPC CIDRs from src
+func LoadCore(src repo.Source) ([]CoreVPC, error) {
+	vpcs := []CoreVPC{}
+	for _, account := range CoreAccounts {
+		file := "terraform/environments/" + account + "/vpc.tf"
+		content, err := src.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		matches := coreCidrPattern.FindAllStringSubmatch(string(content), -1)
+		if len(matches) < len(Domains) {
+			return nil, fmt.Errorf("%s: expected live_data and non_live_data cidrs", file)
+		}
+		for _, match := range matches[:len(Domains)] {
+			vpcs = append(vpcs, CoreVPC{Account: account, Domain: match[1], Cidr: match[2]})
+		}
+	}
+	return vpcs, nil
+}
+
+// Build puts the core VPCs and every subnet set in networks into a graph
+func Build(core []CoreVPC, loaded []networks.Network) Graph {
+	graph := Graph{Core: core}
+	for _, network := range loaded {
+		for _, name := range network.SubnetSetNames() {
+			set := network.Cidr.SubnetSets[name]
+			accounts := append([]string{}, set.Accounts...)
+			sort.Strings(accounts)
+			graph.SubnetSets = append(graph.SubnetSets, SubnetSet{
+				Network:  network.Name,
+				Name:     name,
+				Cidr:     set.Cidr,
+				Domain:   Domain(network.Tier()),
+				Accounts: accounts,
+			})
+		}
+	}
+	return graph
+}
+
+// Load builds the graph from the definitions in src
+func Load(src repo.Source) (Graph, error) {
+	core, err := LoadCore(src)
+	if err != nil {
+		return Graph{}, err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return Graph{}, err
+	}
+	return Build(core, loaded), nil
+}
+
+// node is a vertex in the rendered graph
+type node struct {
+	id    string
+	label string
+}
+
+// edge is a link between two nodes
+type edge struct {
+	from, to string
+}
+
+// domainNodes returns the nodes and edges inside one routing domain. Every VPC
+// attaches to the transit gateway, which routes through the inspection VPC.
+func (g Graph) domainNodes(domain string, accounts bool) ([]node, []edge) {
+	tgw := id("tgw", domain)
+	nodes := []node{{tgw, "transit gateway\\n" + domain}}
+	edges := []edge{}
+
+	for _, vpc := range g.Core {
+		if vpc.Domain != domain {
+			continue
+		}
+		vpcID := id(vpc.Name())
+		if vpc.Account == InspectionAccount {
+			nodes = append(nodes, node{vpcID, "inspection\\n" + vpc.Name() + "\\n" + vpc.Cidr})
+			edges = append(edges, edge{tgw, vpcID})
+			continue
+		}
+		nodes = append(nodes, node{vpcID, vpc.Name() + "\\n" + vpc.Cidr})
+		edges = append(edges, edge{vpcID, tgw})
+	}
+
+	for _, set := range g.SubnetSets {
+		if set.Domain != domain {
+			continue
+		}
+		setID := id(set.Network, set.Name)
+		nodes = append(nodes, node{setID, set.Network + "/" + set.Name + "\\n" + set.Cidr})
+		edges = append(edges, edge{setID, tgw})
+		if accounts {
+			for _, account := range set.Accounts {
+				accountID := id("account", account)
+				nodes = append(nodes, node{accountID, account})
+				edges = append(edges, edge{accountID, setID})
+			}
+		}
+	}
+	return nodes, edges
+}
+
+// WriteDot renders the graph as Graphviz DOT, with one cluster per routing domain
+func (g Graph) WriteDot(w io.Writer, accounts bool) error {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, domain := range Domains {
+		nodes, edges := g.domainNodes(domain, accounts)
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n    label=%q;\n", domain, domain)
+		for _, n := range nodes {
+			fmt.Fprintf(&b, "    %s [label=\"%s\"];\n", n.id, n.label)
+		}
+		for _, e := range edges {
+			fmt.Fprintf(&b, "    %s -> %s;\n", e.from, e.to)
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart, with one subgraph per routing domain
+func (g Graph) WriteMermaid(w io.Writer, accounts bool) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, domain := range Domains {
+		nodes, edges := g.domainNodes(domain, accounts)
+		fmt.Fprintf(&b, "  subgraph %s\n", domain)
+		for _, n := range nodes {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", n.id, strings.ReplaceAll(n.label, "\\n", "<br>"))
+		}
+		for _, e := range edges {
+			fmt.Fprintf(&b, "    %s --> %s\n", e.from, e.to)
+		}
+		b.WriteString("  end\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// id makes a node identifier that is valid in both DOT and Mermaid
+func id(parts ...string) string {
+	return strings.NewReplacer("-", "_", "/", "_", ".", "_").Replace(strings.Join(parts, "_"))
+}
@@ -0,0 +1,89 @@
+package topology
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func files() repotest.Memory {
+	files := repotest.Memory{
+		"environments-networks/hmpps-development.json": `{"cidr": {"subnet_sets": {"general": {"cidr": "10.26.24.0/21", "accounts": ["nomis-development"]}}}, "options": {}}`,
+		"environments-networks/hmpps-production.json":  `{"cidr": {"subnet_sets": {"general": {"cidr": "10.27.0.0/21", "accounts": ["nomis-production"]}}}, "options": {}}`,
+	}
+	for i, account := range CoreAccounts {
+		files["terraform/environments/"+account+"/vpc.tf"] = fmt.Sprintf("locals {\n  networking = {\n    live_data     = \"10.20.%d.0/24\"\n    non_live_data = \"10.21.%d.0/24\"\n  }\n}\n", i, i)
+	}
+	return files
+}
+
+func TestLoad(t *testing.T) {
+	graph, err := Load(files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Core) != 8 {
+		t.Errorf("got %d core vpcs, expected 8", len(graph.Core))
+	}
+	if graph.Core[0].Name() != "core-logging-live_data" || graph.Core[0].Cidr != "10.20.0.0/24" {
+		t.Errorf("got %+v for the first core vpc", graph.Core[0])
+	}
+	domains := map[string]string{}
+	for _, set := range graph.SubnetSets {
+		domains[set.Network] = set.Domain
+	}
+	if domains["hmpps-development"] != "non_live_data" || domains["hmpps-production"] != "live_data" {
+		t.Errorf("got %v, expected development to be non_live_data and production live_data", domains)
+	}
+}
+
+func TestLoadRejectsMissingCidrs(t *testing.T) {
+	src := files()
+	src["terraform/environments/core-security/vpc.tf"] = "locals {}\n"
+	if _, err := Load(src); err == nil {
+		t.Error("expected an error for a core vpc.tf without cidrs")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	graph, err := Load(files())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDot(&dot, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"subgraph cluster_live_data {",
+		"hmpps_production_general [label=\"hmpps-production/general\\n10.27.0.0/21\"];",
+		"hmpps_production_general -> tgw_live_data;",
+		"account_nomis_production -> hmpps_production_general;",
+		"core_network_services_non_live_data [label=\"inspection\\ncore-network-services-non_live_data\\n10.21.1.0/24\"];",
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("expected the DOT output to contain %q, got:\n%s", expected, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := graph.WriteMermaid(&mermaid, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"flowchart LR\n  subgraph live_data\n",
+		"hmpps_development_general[\"hmpps-development/general<br>10.26.24.0/21\"]",
+		"hmpps_development_general --> tgw_non_live_data",
+	} {
+		if !strings.Contains(mermaid.String(), expected) {
+			t.Errorf("expected the Mermaid output to contain %q, got:\n%s", expected, mermaid.String())
+		}
+	}
+	if strings.Contains(mermaid.String(), "account_") {
+		t.Error("expected no account nodes without accounts")
+	}
+}
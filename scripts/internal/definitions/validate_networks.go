@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+func runValidateNetworks(args []string) error {
+	flags, repoRoot := newFlagSet("validate-networks")
+	ref := flags.String("ref", "", "validate the definitions at a git ref instead of the working tree")
+	flags.Parse(args)
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+
+	applications, err := environments.Load(src)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+
+	failures := networks.Validate(loaded, environments.AccountNames(applications))
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("validate-networks: %d failure(s)", len(failures))
+	}
+	fmt.Printf("%d network definitions are valid\n", len(loaded))
+	return nil
+}
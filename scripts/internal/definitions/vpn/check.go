@@ -0,0 +1,143 @@
+package vpn
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+
+	"modernisation-platform/definitions/networks"
+)
+
+var (
+	// linkLocal is where AWS requires tunnel inside CIDRs to be
+	linkLocal = netip.MustParsePrefix("169.254.0.0/16")
+	// reservedInsideCidrs are the /30s AWS doesn't allow as tunnel inside CIDRs
+	reservedInsideCidrs = []string{
+		"169.254.0.0/30",
+		"169.254.1.0/30",
+		"169.254.2.0/30",
+		"169.254.3.0/30",
+		"169.254.4.0/30",
+		"169.254.5.0/30",
+		"169.254.169.252/30",
+	}
+	// reservedAsns are reserved by RFC 6793 and RFC 7300, and can't be used for BGP
+	reservedAsns = []uint64{23456, 65535}
+
+	// DpdTimeoutActions, IkeVersions and StartupActions are the values AWS accepts
+	DpdTimeoutActions = []string{"clear", "none", "restart"}
+	IkeVersions       = []string{"ikev1", "ikev2"}
+	StartupActions    = []string{"add", "start"}
+)
+
+// MaxBgpAsn is the largest bgp_asn an aws_customer_gateway accepts. Larger
+// 4-byte ASNs go in its separate bgp_asn_extended argument, which the VPN
+// attachments don't set.
+const MaxBgpAsn = 2147483647
+
+// MinDpdTimeoutSeconds is the shortest dead peer detection timeout AWS allows
+const MinDpdTimeoutSeconds = 30
+
+// Check checks the VPN attachments against the limits AWS puts on VPN
+// connections and customer gateways, and against each other. tgwAsns are the
+// transit gateways' Amazon side ASNs, which a BGP peer can't share. Warnings
+// are settings AWS accepts that are probably mistakes.
+func Check(attachments []Attachment, defined []networks.Network, tgwAsns []string) (failures, warnings []string) {
+	insideCidrs := map[netip.Prefix]string{}
+	for _, a := range attachments {
+		fail := func(format string, args ...any) {
+			failures = append(failures, fmt.Sprintf("%s: "+format, append([]any{a.Name}, args...)...))
+		}
+		warn := func(format string, args ...any) {
+			warnings = append(warnings, fmt.Sprintf("%s: "+format, append([]any{a.Name}, args...)...))
+		}
+
+		asn, err := strconv.ParseUint(a.BgpAsn.String(), 10, 32)
+		switch {
+		case err != nil || asn < 1 || asn > MaxBgpAsn:
+			fail("bgp_asn %q is not an ASN between 1 and %d", a.BgpAsn, MaxBgpAsn)
+		case bool(a.StaticRoutesOnly):
+			// the ASN isn't used
+		case slices.Contains(tgwAsns, a.BgpAsn.String()):
+			fail("bgp_asn %s is the transit gateway's own ASN", a.BgpAsn)
+		case slices.Contains(reservedAsns, asn):
+			fail("bgp_asn %s is reserved and can't be used for BGP", a.BgpAsn)
+		}
+
+		if ip, err := netip.ParseAddr(a.CustomerGatewayIP); err != nil || !ip.Is4() {
+			fail("customer_gateway_ip %q is not an IPv4 address", a.CustomerGatewayIP)
+		} else if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			warn("customer_gateway_ip %s is not a public address", ip)
+		}
+
+		tunnels := a.InsideCidrs()
+		for _, tunnel := range []string{"tunnel1", "tunnel2"} {
+			cidr, ok := tunnels[tunnel]
+			if !ok {
+				warn("has no %s_inside_cidr, so AWS will pick one at random", tunnel)
+				continue
+			}
+			prefix, err := netip.ParsePrefix(cidr)
+			switch {
+			case err != nil:
+				fail("%s_inside_cidr %q is not a CIDR", tunnel, cidr)
+				continue
+			case prefix.Bits() != 30 || !linkLocal.Contains(prefix.Addr()):
+				fail("%s_inside_cidr %s is not a /30 in %s", tunnel, cidr, linkLocal)
+				continue
+			case prefix != prefix.Masked():
+				fail("%s_inside_cidr %s is not a network address, expected %s", tunnel, cidr, prefix.Masked())
+				continue
+			case slices.Contains(reservedInsideCidrs, cidr):
+				fail("%s_inside_cidr %s is reserved by AWS", tunnel, cidr)
+			}
+			if other, ok := insideCidrs[prefix]; ok {
+				fail("%s_inside_cidr %s is already used by %s", tunnel, cidr, other)
+				continue
+			}
+			insideCidrs[prefix] = a.Name + " " + tunnel
+		}
+
+		if !slices.Contains(DpdTimeoutActions, a.DpdTimeoutAction) && a.DpdTimeoutAction != "" {
+			fail("tunnel_dpd_timeout_action %q is not one of %v", a.DpdTimeoutAction, DpdTimeoutActions)
+		}
+		if a.DpdTimeoutSeconds != "" {
+			seconds, err := strconv.Atoi(a.DpdTimeoutSeconds.String())
+			if err != nil || seconds < MinDpdTimeoutSeconds {
+				fail("tunnel_dpd_timeout_seconds %q is not a whole number of seconds, at least %d", a.DpdTimeoutSeconds, MinDpdTimeoutSeconds)
+			}
+		}
+		if !slices.Contains(StartupActions, a.StartupAction) && a.StartupAction != "" {
+			fail("tunnel_startup_action %q is not one of %v", a.StartupAction, StartupActions)
+		}
+
+		for tunnel, versions := range map[string][]string{"tunnel1": a.Tunnel1IkeVersions, "tunnel2": a.Tunnel2IkeVersions} {
+			if versions != nil && len(versions) == 0 {
+				fail("%s_ike_versions is empty", tunnel)
+			}
+			seen := map[string]bool{}
+			for _, version := range versions {
+				if !slices.Contains(IkeVersions, version) {
+					fail("%s_ike_versions has %q, expected one of %v", tunnel, version, IkeVersions)
+				}
+				if seen[version] {
+					fail("%s_ike_versions lists %q more than once", tunnel, version)
+				}
+				seen[version] = true
+			}
+		}
+
+		if a.RemoteNetwork != "" {
+			if _, err := netip.ParsePrefix(a.RemoteNetwork); err != nil {
+				fail("remote_ipv4_network_cidr %q is not a CIDR", a.RemoteNetwork)
+			}
+		} else if cidr, _ := a.Remote(defined); cidr == "" {
+			fail("has no remote_ipv4_network_cidr, and modernisation_platform_vpc %q is not a network with a general subnet set in %s", a.ModernisationPlatformVpc, networks.Dir)
+		}
+	}
+	sort.Strings(failures)
+	sort.Strings(warnings)
+	return failures, warnings
+}
@@ -0,0 +1,107 @@
+package vpn
+
+import (
+	"strings"
+	"testing"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func TestLoad(t *testing.T) {
+	attachments, err := Load(repotest.Memory{File: `{
+		"b-vpn": {"bgp_asn": 64512, "static_routes_only": "true", "tunnel_dpd_timeout_seconds": "45"},
+		"a-vpn": {"bgp_asn": "64513", "static_routes_only": false}
+	}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 2 || attachments[0].Name != "a-vpn" || attachments[1].Name != "b-vpn" {
+		t.Fatalf("expected a-vpn and b-vpn in order, got %+v", attachments)
+	}
+	if attachments[0].Routing() != "BGP" || attachments[1].Routing() != "static" || attachments[1].BgpAsn != "64512" {
+		t.Errorf("got %+v", attachments)
+	}
+
+	if _, err := Load(repotest.Memory{File: `{"a-vpn": {"static_routes_only": "yes"}}`}); err == nil {
+		t.Error("expected an error for static_routes_only \"yes\"")
+	}
+	if attachments, err := Load(repotest.Memory{}); err != nil || len(attachments) != 0 {
+		t.Errorf("expected no attachments without the file, got %v, %v", attachments, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	valid := Attachment{
+		BgpAsn:             "64532",
+		CustomerGatewayIP:  "51.11.165.198",
+		RemoteNetwork:      "0.0.0.0/0",
+		Tunnel1InsideCidr:  "169.254.21.0/30",
+		Tunnel2InsideCidr:  "169.254.22.0/30",
+		Tunnel1IkeVersions: []string{"ikev1", "ikev2"},
+		DpdTimeoutAction:   "restart",
+		DpdTimeoutSeconds:  "45",
+		StartupAction:      "start",
+	}
+	defined := []networks.Network{{Name: "hmpps-production", Definition: networks.Definition{
+		Cidr: networks.Cidr{SubnetSets: map[string]networks.SubnetSet{"general": {Cidr: "10.27.8.0/21"}}},
+	}}}
+
+	tests := []struct {
+		name     string
+		change   func(a *Attachment)
+		expected string
+	}{
+		{"valid", func(a *Attachment) {}, ""},
+		{"asn range", func(a *Attachment) { a.BgpAsn = "4294967295" }, `bgp_asn "4294967295" is not an ASN`},
+		{"smallest asn", func(a *Attachment) { a.BgpAsn = "1" }, ""},
+		{"asn zero", func(a *Attachment) { a.BgpAsn = "0" }, `bgp_asn "0" is not an ASN between 1 and 2147483647`},
+		{"largest asn", func(a *Attachment) { a.BgpAsn = "2147483647" }, ""},
+		{"4-byte asn", func(a *Attachment) { a.BgpAsn = "2147483648" }, `bgp_asn "2147483648" is not an ASN between 1 and 2147483647`},
+		{"asn not a number", func(a *Attachment) { a.BgpAsn = "AS64532" }, `bgp_asn "AS64532" is not an ASN`},
+		{"tgw asn", func(a *Attachment) { a.BgpAsn = "64589" }, "bgp_asn 64589 is the transit gateway's own ASN"},
+		{"reserved asn", func(a *Attachment) { a.BgpAsn = "65535" }, "bgp_asn 65535 is reserved"},
+		{"reserved asn with static routes", func(a *Attachment) { a.BgpAsn, a.StaticRoutesOnly = "65535", true }, ""},
+		{"gateway", func(a *Attachment) { a.CustomerGatewayIP = "51.11.165" }, `customer_gateway_ip "51.11.165" is not an IPv4 address`},
+		{"inside cidr size", func(a *Attachment) { a.Tunnel1InsideCidr = "169.254.21.0/29" }, "tunnel1_inside_cidr 169.254.21.0/29 is not a /30 in 169.254.0.0/16"},
+		{"inside cidr range", func(a *Attachment) { a.Tunnel2InsideCidr = "10.0.0.0/30" }, "tunnel2_inside_cidr 10.0.0.0/30 is not a /30"},
+		{"inside cidr host bits", func(a *Attachment) { a.Tunnel1InsideCidr = "169.254.21.1/30" }, "expected 169.254.21.0/30"},
+		{"reserved inside cidr", func(a *Attachment) { a.Tunnel1InsideCidr = "169.254.169.252/30" }, "tunnel1_inside_cidr 169.254.169.252/30 is reserved"},
+		{"reused inside cidr", func(a *Attachment) { a.Tunnel2InsideCidr = a.Tunnel1InsideCidr }, "tunnel2_inside_cidr 169.254.21.0/30 is already used by vpn tunnel1"},
+		{"dpd action", func(a *Attachment) { a.DpdTimeoutAction = "reset" }, `tunnel_dpd_timeout_action "reset" is not one of`},
+		{"dpd seconds", func(a *Attachment) { a.DpdTimeoutSeconds = "10" }, `tunnel_dpd_timeout_seconds "10" is not`},
+		{"startup action", func(a *Attachment) { a.StartupAction = "begin" }, `tunnel_startup_action "begin" is not one of`},
+		{"ike version", func(a *Attachment) { a.Tunnel2IkeVersions = []string{"ikev3"} }, `tunnel2_ike_versions has "ikev3"`},
+		{"ike versions empty", func(a *Attachment) { a.Tunnel1IkeVersions = []string{} }, "tunnel1_ike_versions is empty"},
+		{"remote network", func(a *Attachment) { a.RemoteNetwork, a.ModernisationPlatformVpc = "", "hmpps-production" }, ""},
+		{"unknown remote network", func(a *Attachment) { a.RemoteNetwork, a.ModernisationPlatformVpc = "", "hmpps-test" }, `modernisation_platform_vpc "hmpps-test" is not a network`},
+	}
+	for _, test := range tests {
+		a := valid
+		a.Name = "vpn"
+		test.change(&a)
+		failures, _ := Check([]Attachment{a}, defined, []string{"64589"})
+		got := strings.Join(failures, "\n")
+		if (test.expected == "" && got != "") || !strings.Contains(got, test.expected) {
+			t.Errorf("%s: got %q, expected %q", test.name, got, test.expected)
+		}
+	}
+
+	// inside cidrs must be unique across every VPN, not only within one
+	other := valid
+	other.Name, other.Tunnel1InsideCidr, other.Tunnel2InsideCidr = "other", "169.254.21.4/30", "169.254.22.0/30"
+	first := valid
+	first.Name = "first"
+	failures, _ := Check([]Attachment{first, other}, defined, nil)
+	if len(failures) != 1 || failures[0] != "other: tunnel2_inside_cidr 169.254.22.0/30 is already used by first tunnel2" {
+		t.Errorf("got %v", failures)
+	}
+
+	missing := valid
+	missing.Name, missing.Tunnel2InsideCidr, missing.CustomerGatewayIP = "missing", "", "10.0.0.1"
+	_, warnings := Check([]Attachment{missing}, defined, nil)
+	expected := "missing: customer_gateway_ip 10.0.0.1 is not a public address\nmissing: has no tunnel2_inside_cidr, so AWS will pick one at random"
+	if strings.Join(warnings, "\n") != expected {
+		t.Errorf("got warnings:\n%s\nexpected:\n%s", strings.Join(warnings, "\n"), expected)
+	}
+}
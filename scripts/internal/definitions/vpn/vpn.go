@@ -0,0 +1,132 @@
+// Package vpn models the site-to-site VPN attachments in
+// terraform/environments/core-network-services/vpn_attachments.json, which
+// vpn.tf turns into customer gateways and transit gateway VPN connections.
+package vpn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// File is the location of the VPN attachments relative to the repository root
+const File = "terraform/environments/core-network-services/vpn_attachments.json"
+
+// TransitGatewayFile defines the transit gateway the VPNs attach to
+const TransitGatewayFile = "terraform/environments/core-network-services/transit-gateway.tf"
+
+// Bool is a boolean that, as Terraform does, also accepts "true" and "false"
+type Bool bool
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false":
+		*b = false
+	default:
+		return fmt.Errorf("expected true or false, got %s", data)
+	}
+	return nil
+}
+
+// Attachment is one VPN in vpn_attachments.json. vpn.tf applies the tunnel_
+// settings to both tunnels, other than the inside CIDRs and IKE versions.
+type Attachment struct {
+	Name              string      `json:"-"`
+	BgpAsn            json.Number `json:"bgp_asn"`
+	CustomerGatewayIP string      `json:"customer_gateway_ip"`
+	StaticRoutesOnly  Bool        `json:"static_routes_only"`
+	// RemoteNetwork is remote_ipv4_network_cidr; without it the VPN's remote
+	// network is the general subnet set of ModernisationPlatformVpc
+	RemoteNetwork            string      `json:"remote_ipv4_network_cidr"`
+	ModernisationPlatformVpc string      `json:"modernisation_platform_vpc"`
+	Tunnel1InsideCidr        string      `json:"tunnel1_inside_cidr"`
+	Tunnel2InsideCidr        string      `json:"tunnel2_inside_cidr"`
+	Tunnel1IkeVersions       []string    `json:"tunnel1_ike_versions"`
+	Tunnel2IkeVersions       []string    `json:"tunnel2_ike_versions"`
+	DpdTimeoutAction         string      `json:"tunnel_dpd_timeout_action"`
+	DpdTimeoutSeconds        json.Number `json:"tunnel_dpd_timeout_seconds"`
+	StartupAction            string      `json:"tunnel_startup_action"`
+	DxGatewayID              string      `json:"dx_gateway_id"`
+}
+
+// Routing is how the VPN learns routes: BGP, or static routes only
+func (a Attachment) Routing() string {
+	if a.StaticRoutesOnly {
+		return "static"
+	}
+	return "BGP"
+}
+
+// InsideCidrs returns the tunnel inside CIDRs that are set, by tunnel
+func (a Attachment) InsideCidrs() map[string]string {
+	cidrs := map[string]string{}
+	for tunnel, cidr := range map[string]string{"tunnel1": a.Tunnel1InsideCidr, "tunnel2": a.Tunnel2InsideCidr} {
+		if cidr != "" {
+			cidrs[tunnel] = cidr
+		}
+	}
+	return cidrs
+}
+
+// Remote returns the VPN's remote IPv4 network and where it comes from, as
+// vpn.tf works it out, or "" if the network it refers to isn't defined
+func (a Attachment) Remote(defined []networks.Network) (cidr, source string) {
+	if a.RemoteNetwork != "" {
+		return a.RemoteNetwork, "remote_ipv4_network_cidr"
+	}
+	for _, network := range defined {
+		if network.Name == a.ModernisationPlatformVpc {
+			if general, ok := network.Cidr.SubnetSets["general"]; ok {
+				return general.Cidr, network.Name + " general subnet set"
+			}
+		}
+	}
+	return "", ""
+}
+
+// Load reads the VPN attachments, sorted by name. Like locals.tf, a missing
+// file means there are none.
+func Load(src repo.Source) ([]Attachment, error) {
+	content, err := src.ReadFile(File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var byName map[string]Attachment
+	if err := json.Unmarshal(content, &byName); err != nil {
+		return nil, fmt.Errorf("%s: %w", File, err)
+	}
+	attachments := make([]Attachment, 0, len(byName))
+	for name, attachment := range byName {
+		attachment.Name = name
+		attachments = append(attachments, attachment)
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].Name < attachments[j].Name })
+	return attachments, nil
+}
+
+var amazonSideAsn = regexp.MustCompile(`(?m)^\s*amazon_side_asn\s*=\s*"?([0-9]+)"?`)
+
+// LoadTransitGatewayAsns returns the Amazon side ASNs of the transit gateways
+func LoadTransitGatewayAsns(src repo.Source) ([]string, error) {
+	content, err := src.ReadFile(TransitGatewayFile)
+	if err != nil {
+		return nil, err
+	}
+	asns := []string{}
+	for _, match := range amazonSideAsn.FindAllStringSubmatch(string(content), -1) {
+		asns = append(asns, match[1])
+	}
+	return asns, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo"
+)
+
+// location is where an account sits in the platform's networks
+type location struct {
+	account   string
+	found     bool
+	isolated  bool
+	network   networks.Network
+	subnetSet string
+	cidr      string
+}
+
+func runWhereis(args []string) error {
+	flags, repoRoot := newFlagSet("whereis")
+	ref := flags.String("ref", "", "read the definitions at a git ref instead of the working tree")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: definitions whereis [flags] <application>-<environment>")
+		flags.PrintDefaults()
+	}
+
+	// allow the account before or after the flags
+	var account string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		account, args = args[0], args[1:]
+	}
+	flags.Parse(args)
+	if account == "" && flags.NArg() > 0 {
+		account = flags.Arg(0)
+	}
+	if account == "" {
+		flags.Usage()
+		return fmt.Errorf("whereis: an account name is required")
+	}
+
+	root, err := resolveRepoRoot(*repoRoot)
+	if err != nil {
+		return err
+	}
+	src, err := repo.Open(root, *ref)
+	if err != nil {
+		return err
+	}
+	applications, err := environments.Load(src)
+	if err != nil {
+		return err
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		return err
+	}
+
+	loc, err := locate(account, applications, loaded)
+	if err != nil {
+		return err
+	}
+	return loc.write(os.Stdout)
+}
+
+// locate finds account in the environment definitions and the network it's a member of
+func locate(account string, applications []environments.Application, loaded []networks.Network) (location, error) {
+	loc := location{account: account}
+
+	defined := false
+	for _, app := range applications {
+		for _, acc := range app.Accounts() {
+			if acc.Name() == account {
+				defined = true
+				loc.isolated = app.Isolated()
+			}
+		}
+	}
+
+	for _, network := range loaded {
+		for _, name := range network.SubnetSetNames() {
+			set := network.Cidr.SubnetSets[name]
+			for _, member := range set.Accounts {
+				if member == account {
+					loc.found = true
+					loc.network = network
+					loc.subnetSet = name
+					loc.cidr = set.Cidr
+				}
+			}
+		}
+	}
+
+	if !defined && !loc.found {
+		return loc, fmt.Errorf("whereis: %s is not an environment in %s/*.json or a member of any network", account, environments.Dir)
+	}
+	return loc, nil
+}
+
+func (l location) write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", l.account)
+	if l.isolated {
+		b.WriteString("  isolated-network: true, the account has its own VPC rather than a subnet set in a shared network\n")
+	}
+	if !l.found {
+		fmt.Fprintf(&b, "  not a member of any subnet set in %s\n", networks.Dir)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	endpoints := "none"
+	if len(l.network.Options.AdditionalEndpoints) > 0 {
+		endpoints = strings.Join(l.network.Options.AdditionalEndpoints, ", ")
+	}
+	fmt.Fprintf(&b, "  network:              %s (%s/%s.json)\n", l.network.Name, networks.Dir, l.network.Name)
+	fmt.Fprintf(&b, "  subnet set:           %s\n", l.subnetSet)
+	fmt.Fprintf(&b, "  cidr:                 %s\n", l.cidr)
+	fmt.Fprintf(&b, "  bastion:              %t\n", l.network.Options.BastionLinux)
+	fmt.Fprintf(&b, "  additional endpoints: %s\n", endpoints)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
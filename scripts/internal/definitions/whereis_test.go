@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"modernisation-platform/definitions/environments"
+	"modernisation-platform/definitions/networks"
+	"modernisation-platform/definitions/repo/repotest"
+)
+
+func TestLocate(t *testing.T) {
+	src := repotest.Memory{
+		"environments/nomis.json":    `{"account-type": "member", "environments": [{"name": "development"}, {"name": "production"}]}`,
+		"environments/isolated.json": `{"account-type": "member", "isolated-network": "true", "environments": [{"name": "development"}]}`,
+		"environments-networks/hmpps-development.json": `{
+			"cidr": {"subnet_sets": {"general": {"cidr": "10.26.24.0/21", "accounts": ["nomis-development"]}}},
+			"options": {"bastion_linux": true, "additional_endpoints": ["com.amazonaws.eu-west-2.athena"]}
+		}`,
+	}
+	applications, err := environments.Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := networks.Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		account  string
+		expected string
+	}{
+		{"nomis-development", "nomis-development\n" +
+			"  network:              hmpps-development (environments-networks/hmpps-development.json)\n" +
+			"  subnet set:           general\n" +
+			"  cidr:                 10.26.24.0/21\n" +
+			"  bastion:              true\n" +
+			"  additional endpoints: com.amazonaws.eu-west-2.athena\n"},
+		{"nomis-production", "nomis-production\n  not a member of any subnet set in environments-networks\n"},
+		{"isolated-development", "isolated-development\n" +
+			"  isolated-network: true, the account has its own VPC rather than a subnet set in a shared network\n" +
+			"  not a member of any subnet set in environments-networks\n"},
+	}
+	for _, test := range tests {
+		loc, err := locate(test.account, applications, loaded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := loc.write(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("got:\n%s\nexpected:\n%s", out.String(), test.expected)
+		}
+	}
+
+	if _, err := locate("missing-development", applications, loaded); err == nil {
+		t.Error("expected an error for an unknown account")
+	}
+}
@@ -0,0 +1,7 @@
+module modernisation-platform/get-application-data-summary
+
+go 1.23
+
+require modernisation-platform/definitions v0.0.0
+
+replace modernisation-platform/definitions => ../definitions
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"modernisation-platform/definitions/repo"
+)
+
+// definitionSource provides the raw environment definitions, keyed by file name
+type definitionSource interface {
+	ReadDefinitions() (map[string][]byte, error)
+}
+
+// dirSource reads definitions from a directory on disk
+type dirSource struct {
+	dir string
+}
+
+func (s dirSource) ReadDefinitions() (map[string][]byte, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := map[string][]byte{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		jsonData, err := os.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		definitions[file.Name()] = jsonData
+	}
+	return definitions, nil
+}
+
+// gitSource reads definitions from a git ref without checking it out
+type gitSource struct {
+	ref repo.Source
+	// dir is slash-separated and relative to the repository root
+	dir string
+}
+
+func (s gitSource) ReadDefinitions() (map[string][]byte, error) {
+	names, err := s.ref.ListFiles(s.dir, ".json")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no definitions in %s at %s", s.dir, s.ref)
+	}
+
+	definitions := map[string][]byte{}
+	for _, name := range names {
+		jsonData, err := s.ref.ReadFile(path.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		definitions[name] = jsonData
+	}
+	return definitions, nil
+}
+
+// newDefinitionSource works out where to read definitions from. repoRoot is
+// discovered from the working directory when empty, and environmentsDir
+// defaults to the environments directory at the repository root.
+func newDefinitionSource(repoRoot, environmentsDir, ref string) (definitionSource, error) {
+	if environmentsDir != "" && ref == "" {
+		return dirSource{dir: environmentsDir}, nil
+	}
+
+	if repoRoot == "" {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if repoRoot, err = repo.FindRoot(workingDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if environmentsDir == "" {
+		environmentsDir = filepath.Join(repoRoot, "environments")
+	}
+
+	if ref == "" {
+		return dirSource{dir: environmentsDir}, nil
+	}
+
+	absRoot, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(environmentsDir)
+	if err != nil {
+		return nil, err
+	}
+	relDir, err := filepath.Rel(absRoot, absDir)
+	if err != nil || strings.HasPrefix(relDir, "..") {
+		return nil, fmt.Errorf("%s is not inside the repository %s", environmentsDir, repoRoot)
+	}
+	gitRef, err := repo.OpenRef(absRoot, ref)
+	if err != nil {
+		return nil, err
+	}
+	return gitSource{ref: gitRef, dir: filepath.ToSlash(relDir)}, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDefinitionSourceDefaultsToRepoEnvironments(t *testing.T) {
+	src, err := newDefinitionSource("/repo", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, ok := src.(dirSource); !ok || dir.dir != filepath.Join("/repo", "environments") {
+		t.Errorf("got %#v, expected the environments directory under the repository root", src)
+	}
+}
+
+func TestNewDefinitionSourceRejectsDirOutsideRepo(t *testing.T) {
+	if _, err := newDefinitionSource("/repo", "/elsewhere/environments", "main"); err == nil {
+		t.Error("expected an error for an environments directory outside the repository")
+	}
+}
+
+func TestGitSourceReadsRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("environments/alpha.json", `{"account-type": "member"}`)
+	write("environments/README.md", "not a definition")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	// Changes in the working tree should not be visible at the ref
+	write("environments/alpha.json", `{"account-type": "core"}`)
+	write("environments/bravo.json", `{"account-type": "member"}`)
+
+	if _, err := newDefinitionSource(root, "", "no-such-ref"); err == nil {
+		t.Error("expected an error for a ref that doesn't exist")
+	}
+
+	src, err := newDefinitionSource(root, "", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	applications, err := loadApplications(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applications) != 1 || applications[0].Name != "alpha" {
+		t.Fatalf("got %+v, expected only alpha", applications)
+	}
+	if applications[0].Environment.AccountType != "member" {
+		t.Errorf("got account type %q from the ref, expected member", applications[0].Environment.AccountType)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Environment struct {
+	AccountType     string      `json:"account-type"`
+	IsolatedNetwork string      `json:"isolated-network"`
+	Components      []Component `json:"components"`
+	GoLiveDate      string      `json:"go-live-date"`
+	Tags            Tags        `json:"tags"`
+}
+
+type Component struct {
+	Name         string `json:"name"`
+	SsoGroupName string `json:"sso_group_name"`
+}
+
+type Tags struct {
+	Application                    string `json:"application"`
+	BusinessUnit                   string `json:"business-unit"`
+	InfrastructureSupport          string `json:"infrastructure-support"`
+	Owner                          string `json:"owner"`
+	SlackChannel                   string `json:"slack-channel"`
+	CriticalNationalInfrastructure bool   `json:"critical-national-infrastructure"`
+}
+
+// Application is a single environments/<name>.json definition
+type Application struct {
+	Name        string
+	Environment Environment
+}
+
+// Accounts owned and run by the Modernisation Platform team rather than a member team
+var mpOwnedApplications = []string{"example", "sprinkler", "cooker", "testing"}
+
+func (a Application) mpOwned() bool {
+	for _, name := range mpOwnedApplications {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// goLiveDate returns the parsed go-live date, or false if there isn't a valid one
+func (a Application) goLiveDate() (time.Time, bool) {
+	if a.Environment.GoLiveDate == "" {
+		return time.Time{}, false
+	}
+	parsedDate, err := time.Parse("2006-01-02", a.Environment.GoLiveDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsedDate, true
+}
+
+// Category is one section of the summary. Every category is evaluated against
+// every application, so a new entry in categories is counted and rendered in
+// every output format without any further changes.
+type Category struct {
+	Name    string
+	Matches func(app Application, today time.Time) bool
+	// Entry formats a matching application, defaults to the application name
+	Entry func(app Application) string
+}
+
+var categories = []Category{
+	{
+		Name: "Member applications",
+		Matches: func(app Application, today time.Time) bool {
+			return app.Environment.AccountType == "member" && !app.mpOwned()
+		},
+	},
+	{
+		Name: "Member-unrestricted applications",
+		Matches: func(app Application, today time.Time) bool {
+			return app.Environment.AccountType == "member-unrestricted" && !app.mpOwned()
+		},
+	},
+	{
+		Name: "MP controlled applications",
+		Matches: func(app Application, today time.Time) bool {
+			return app.Environment.AccountType == "core" || app.mpOwned()
+		},
+	},
+	{
+		Name: "Upcoming migrations",
+		Matches: func(app Application, today time.Time) bool {
+			goLive, ok := app.goLiveDate()
+			return ok && app.Environment.AccountType == "member" && goLive.After(today)
+		},
+		Entry: goLiveEntry,
+	},
+	{
+		Name: "Live in production applications",
+		Matches: func(app Application, today time.Time) bool {
+			goLive, ok := app.goLiveDate()
+			return ok && app.Environment.AccountType == "member" && goLive.Before(today)
+		},
+		Entry: goLiveEntry,
+	},
+	{
+		Name: "Critical National Infrastructure applications",
+		Matches: func(app Application, today time.Time) bool {
+			return app.Environment.Tags.CriticalNationalInfrastructure
+		},
+	},
+	{
+		Name: "Isolated network applications",
+		Matches: func(app Application, today time.Time) bool {
+			return app.Environment.IsolatedNetwork == "true"
+		},
+	},
+	{
+		Name: "Applications with components",
+		Matches: func(app Application, today time.Time) bool {
+			return len(app.Environment.Components) > 0
+		},
+	},
+}
+
+func goLiveEntry(app Application) string {
+	return app.Environment.GoLiveDate + " " + app.Name
+}
+
+// Result holds the entries matching a category
+type Result struct {
+	Category string   `json:"category"`
+	Count    int      `json:"count"`
+	Entries  []string `json:"entries"`
+}
+
+// loadApplications parses every definition from src, skipping (and logging) any that can't be parsed
+func loadApplications(src definitionSource) ([]Application, error) {
+	definitions, err := src.ReadDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	applications := []Application{}
+	for fileName, jsonData := range definitions {
+		var env Environment
+		if err := json.Unmarshal(jsonData, &env); err != nil {
+			log.Printf("%s: %v", fileName, err)
+			continue
+		}
+
+		applications = append(applications, Application{
+			Name:        strings.TrimSuffix(fileName, ".json"),
+			Environment: env,
+		})
+	}
+
+	sort.Slice(applications, func(i, j int) bool { return applications[i].Name < applications[j].Name })
+	return applications, nil
+}
+
+// summarise evaluates every category against every application
+func summarise(applications []Application, today time.Time) []Result {
+	results := make([]Result, 0, len(categories))
+	for _, category := range categories {
+		result := Result{Category: category.Name, Entries: []string{}}
+		for _, app := range applications {
+			if !category.Matches(app, today) {
+				continue
+			}
+			entry := app.Name
+			if category.Entry != nil {
+				entry = category.Entry(app)
+			}
+			result.Entries = append(result.Entries, entry)
+		}
+		result.Count = len(result.Entries)
+		results = append(results, result)
+	}
+	return results
+}
+
+var formats = map[string]func(w io.Writer, results []Result) error{
+	"text":     renderText,
+	"markdown": renderMarkdown,
+	"json":     renderJSON,
+}
+
+func render(w io.Writer, format string, results []Result) error {
+	renderer, ok := formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of: text, markdown, json", format)
+	}
+	return renderer(w, results)
+}
+
+func renderText(w io.Writer, results []Result) error {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d):\n", result.Category, result.Count)
+		for _, entry := range result.Entries {
+			fmt.Fprintln(w, entry)
+		}
+	}
+	return nil
+}
+
+func renderMarkdown(w io.Writer, results []Result) error {
+	fmt.Fprintln(w, "| Category | Count |")
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, result := range results {
+		fmt.Fprintf(w, "| %s | %d |\n", result.Category, result.Count)
+	}
+	for _, result := range results {
+		fmt.Fprintf(w, "\n## %s (%d)\n\n", result.Category, result.Count)
+		for _, entry := range result.Entries {
+			fmt.Fprintf(w, "- %s\n", entry)
+		}
+	}
+	return nil
+}
+
+func renderJSON(w io.Writer, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fixtureToday = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func loadFixtures(t *testing.T) []Application {
+	t.Helper()
+	applications, err := loadApplications(dirSource{dir: "testdata/environments"})
+	if err != nil {
+		t.Fatalf("loading fixtures: %v", err)
+	}
+	return applications
+}
+
+func TestLoadApplicationsSkipsInvalidFiles(t *testing.T) {
+	applications := loadFixtures(t)
+
+	names := []string{}
+	for _, app := range applications {
+		names = append(names, app.Name)
+	}
+	expected := []string{"alpha", "bravo", "charlie", "core-logging", "delta", "sprinkler"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("got applications %v, expected %v", names, expected)
+	}
+}
+
+func TestSummariseCategories(t *testing.T) {
+	results := summarise(loadFixtures(t), fixtureToday)
+
+	expected := map[string][]string{
+		"Member applications":                           {"alpha", "delta"},
+		"Member-unrestricted applications":              {"bravo", "charlie"},
+		"MP controlled applications":                    {"core-logging", "sprinkler"},
+		"Upcoming migrations":                           {"2030-01-01 delta"},
+		"Live in production applications":               {"2024-01-01 alpha"},
+		"Critical National Infrastructure applications": {"alpha"},
+		"Isolated network applications":                 {"charlie"},
+		"Applications with components":                  {"sprinkler"},
+	}
+
+	if len(results) != len(categories) {
+		t.Fatalf("got %d results, expected one per category (%d)", len(results), len(categories))
+	}
+	for _, result := range results {
+		want, ok := expected[result.Category]
+		if !ok {
+			t.Errorf("no expectation for category %q", result.Category)
+			continue
+		}
+		if result.Count != len(result.Entries) {
+			t.Errorf("%s: count %d does not match %d entries", result.Category, result.Count, len(result.Entries))
+		}
+		if strings.Join(result.Entries, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got %v, expected %v", result.Category, result.Entries, want)
+		}
+	}
+}
+
+func TestRenderFormatsUseCategoryCounts(t *testing.T) {
+	results := summarise(loadFixtures(t), fixtureToday)
+
+	for format := range formats {
+		var out bytes.Buffer
+		if err := render(&out, format, results); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		if format == "json" {
+			var decoded []Result
+			if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+				t.Fatalf("json output does not parse: %v", err)
+			}
+			for i, result := range decoded {
+				if result.Count != results[i].Count {
+					t.Errorf("json: %s count %d, expected %d", result.Category, result.Count, results[i].Count)
+				}
+			}
+			continue
+		}
+
+		for _, result := range results {
+			heading := fmt.Sprintf("%s (%d)", result.Category, result.Count)
+			if !strings.Contains(out.String(), heading) {
+				t.Errorf("%s: output is missing %q", format, heading)
+			}
+		}
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if err := render(&bytes.Buffer{}, "yaml", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,130 @@
+package coretest
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// Equals checks a string or number output
+func Equals(output, expected string) Check {
+	return Check{output, func(t *testing.T, outputs Outputs) {
+		assert.Equal(t, expected, outputs.String(t, output))
+	}}
+}
+
+// Count checks a numeric output, such as the length of a list of subnet IDs
+func Count(output string, expected int) Check {
+	return Check{output, func(t *testing.T, outputs Outputs) {
+		count, err := strconv.Atoi(outputs.String(t, output))
+		if assert.NoError(t, err, "%s is not a number", output) {
+			assert.Equal(t, expected, count)
+		}
+	}}
+}
+
+// MapEquals checks a map(string) output
+func MapEquals(output string, expected map[string]string) Check {
+	return Check{output, func(t *testing.T, outputs Outputs) {
+		assert.Equal(t, expected, outputs.Map(t, output))
+	}}
+}
+
+// MapKeySuffixes checks that a map output has a key ending in each suffix,
+// e.g. live_data-private-eu-west-2a for private-eu-west-2a
+func MapKeySuffixes(output string, suffixes ...string) Check {
+	return Check{output, func(t *testing.T, outputs Outputs) {
+		values := outputs.Map(t, output)
+		for _, suffix := range suffixes {
+			assert.True(t, hasKeySuffix(values, suffix), "%s has no key ending in %s, got %v", output, suffix, values)
+		}
+	}}
+}
+
+func hasKeySuffix(values map[string]string, suffix string) bool {
+	for key := range values {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONEquals checks any output against the JSON encoding of expected, such as
+// a map of lists
+func JSONEquals(output string, expected any) Check {
+	return Check{output, func(t *testing.T, outputs Outputs) {
+		content, err := json.Marshal(expected)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, string(content), outputs.JSON(t, output))
+		}
+	}}
+}
+
+// ResourceCount checks the number of planned resources of a type, e.g.
+// aws_subnet. It is skipped unless the outputs were read from a plan.
+func ResourceCount(resourceType string, expected int) Check {
+	return ModuleResourceCount("", resourceType, expected)
+}
+
+// ModuleResourceCount checks the number of planned resources of a type in a
+// module instance, e.g. module.vpc["hmpps-development"], or in the whole stack
+// for "". It is skipped unless the outputs were read from a plan.
+func ModuleResourceCount(module, resourceType string, expected int) Check {
+	return Check{strings.TrimSpace(module + " " + resourceType), func(t *testing.T, outputs Outputs) {
+		assert.Len(t, moduleResources(t, outputs, module, resourceType), expected)
+	}}
+}
+
+// ModuleAttributeValues checks that a module instance has a planned resource
+// of a type with each of the expected values of an attribute, e.g. an
+// aws_vpc_endpoint for each service_name. It is skipped unless the outputs
+// were read from a plan.
+func ModuleAttributeValues(module, resourceType, attribute string, expected ...string) Check {
+	return Check{module + " " + resourceType + " " + attribute, func(t *testing.T, outputs Outputs) {
+		values := []string{}
+		for _, resource := range moduleResources(t, outputs, module, resourceType) {
+			values = append(values, format(resource.AttributeValues[attribute]))
+		}
+		for _, value := range expected {
+			assert.Contains(t, values, value, "%s has no %s with %s %s", module, resourceType, attribute, value)
+		}
+	}}
+}
+
+// ResourceExists checks that a resource, e.g. aws_iam_role.member-delegation["hmpps-development"],
+// is planned. It is skipped unless the outputs were read from a plan.
+func ResourceExists(address string) Check {
+	return Check{address, func(t *testing.T, outputs Outputs) {
+		_, ok := plannedResources(t, outputs)[address]
+		assert.True(t, ok, "%s is not in the plan", address)
+	}}
+}
+
+// plannedResources returns the planned resources by address, skipping the
+// test if the outputs weren't read from a plan
+func plannedResources(t *testing.T, outputs Outputs) map[string]*tfjson.StateResource {
+	t.Helper()
+	planned, ok := outputs.(PlannedResources)
+	if !ok {
+		t.Skip("resource checks need -plan")
+	}
+	return planned.Resources(t)
+}
+
+// moduleResources returns the planned resources of a type in a module
+// instance and the modules it calls, or in the whole stack for ""
+func moduleResources(t *testing.T, outputs Outputs, module, resourceType string) []*tfjson.StateResource {
+	t.Helper()
+	resources := []*tfjson.StateResource{}
+	for address, resource := range plannedResources(t, outputs) {
+		if resource.Type == resourceType && resource.Mode == tfjson.ManagedResourceMode && (module == "" || strings.HasPrefix(address, module+".")) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
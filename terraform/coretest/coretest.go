@@ -0,0 +1,91 @@
+// Package coretest holds the assertions shared by the core account Terratest suites.
+package coretest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Outputs gives typed access to a stack's Terraform outputs
+type Outputs interface {
+	// String returns an output as a string, e.g. "3" for a number
+	String(t *testing.T, name string) string
+	// Map returns a map(string) output
+	Map(t *testing.T, name string) map[string]string
+	// JSON returns any output as JSON
+	JSON(t *testing.T, name string) string
+	// All returns every output, decoded from JSON, with sensitive values
+	// replaced by SensitiveValue
+	All(t *testing.T) map[string]any
+}
+
+const (
+	// SensitiveValue stands in for the value of a sensitive output
+	SensitiveValue = "(sensitive value)"
+	// KnownAfterApply stands in for an output a plan doesn't know yet
+	KnownAfterApply = "(known after apply)"
+)
+
+// stateOutputs reads outputs from the stack's state
+type stateOutputs struct {
+	options *terraform.Options
+}
+
+func (s stateOutputs) String(t *testing.T, name string) string {
+	return terraform.Output(t, s.options, name)
+}
+
+func (s stateOutputs) Map(t *testing.T, name string) map[string]string {
+	return terraform.OutputMap(t, s.options, name)
+}
+
+func (s stateOutputs) JSON(t *testing.T, name string) string {
+	return terraform.OutputJson(t, s.options, name)
+}
+
+func (s stateOutputs) All(t *testing.T) map[string]any {
+	var outputs map[string]struct {
+		Sensitive bool `json:"sensitive"`
+		Value     any  `json:"value"`
+	}
+	content := terraform.RunTerraformCommandAndGetStdout(t, s.options, "output", "-json")
+	if err := json.Unmarshal([]byte(content), &outputs); err != nil {
+		t.Fatalf("terraform output -json: %s", err)
+	}
+	values := map[string]any{}
+	for name, output := range outputs {
+		values[name] = output.Value
+		if output.Sensitive {
+			values[name] = SensitiveValue
+		}
+	}
+	return values
+}
+
+// Refresh refreshes the stack in dir and checks it plans, then returns its outputs
+func Refresh(t *testing.T, dir string) Outputs {
+	options := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: dir,
+	})
+
+	terraform.RunTerraformCommand(t, options, "refresh")
+	terraform.Plan(t, options)
+	return stateOutputs{options}
+}
+
+// Check is a single named assertion against a stack's outputs
+type Check struct {
+	Name   string
+	Assert func(t *testing.T, outputs Outputs)
+}
+
+// Run runs each check as a subtest
+func Run(t *testing.T, outputs Outputs, checks []Check) {
+	for _, check := range checks {
+		t.Run(check.Name, func(t *testing.T) {
+			check.Assert(t, outputs)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package coretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var updateFlag = flag.Bool("update", false, "write the stack's outputs to its golden file instead of comparing them")
+
+// GoldenDir holds a suite's golden files, one per account, relative to the test's working directory
+const GoldenDir = "testdata/golden"
+
+// GoldenPath returns the golden file for an account's outputs, e.g.
+// testdata/golden/core-logging-production.json. Outputs read from a plan
+// have their own golden file, <account>.plan.json, as values only known
+// after apply are missing from them.
+func GoldenPath(account string, outputs Outputs) string {
+	if _, ok := outputs.(PlannedResources); ok {
+		return filepath.Join(GoldenDir, account+".plan.json")
+	}
+	return filepath.Join(GoldenDir, account+".json")
+}
+
+// Golden is a check that the stack's outputs match the account's golden file
+func Golden(account string) Check {
+	return Check{"golden", func(t *testing.T, outputs Outputs) {
+		MatchGolden(t, outputs, account)
+	}}
+}
+
+// MatchGolden compares all of a stack's outputs with the account's reviewed
+// golden file, as a subtest per output that lists every value that was added,
+// removed or changed. With -update it writes the outputs to the golden file
+// instead, to be reviewed and committed. Sensitive outputs are never written,
+// and resource and account IDs are masked. The test fails if the account has
+// no golden file.
+func MatchGolden(t *testing.T, outputs Outputs, account string) {
+	t.Helper()
+	path := GoldenPath(account, outputs)
+	actual := map[string]any{}
+	for name, value := range outputs.All(t) {
+		actual[name] = mask(value)
+	}
+
+	if *updateFlag {
+		content, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("wrote %d outputs to %s, review the change before committing it", len(actual), path)
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("%s has no golden file, record %s by running go test -update in its workspace, or go test -plan <plan> -update for a saved plan, then review and commit it", account, path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected map[string]any
+	if err := json.Unmarshal(content, &expected); err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+
+	for _, name := range outputNames(expected, actual) {
+		t.Run(name, func(t *testing.T) {
+			for _, difference := range diffValues(name, expected[name], actual[name]) {
+				t.Error(difference)
+			}
+			if t.Failed() {
+				t.Logf("if the change is intended, run go test -update and commit %s", path)
+			}
+		})
+	}
+}
+
+// MatchGoldenFile compares content with a golden file, such as rules rendered
+// from the stack's configuration, or writes it there with -update
+func MatchGoldenFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if *updateFlag {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("wrote %s, review the change before committing it", path)
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("%s doesn't exist, run go test -update to record it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, content) {
+		t.Errorf("%s is out of date, run go test -update and commit the diff", path)
+	}
+}
+
+var (
+	// resourceID matches an AWS resource ID, e.g. vpc-0123456789abcdef0 or tgw-attach-01234567
+	resourceID = regexp.MustCompile(`^([a-z]+(?:-[a-z]+)*)-(?:[0-9a-f]{8}|[0-9a-f]{17})$`)
+	// arnAccount matches the account ID in an ARN
+	arnAccount = regexp.MustCompile(`^(arn:[^:]*:[^:]*:[^:]*:)[0-9]{12}:`)
+)
+
+// mask replaces resource IDs, and account IDs in ARNs, with placeholders that
+// keep the kind of resource, e.g. vpc-(id). IDs change whenever a resource is
+// replaced, and account IDs aren't committed to the repository.
+func mask(value any) any {
+	switch v := value.(type) {
+	case string:
+		v = resourceID.ReplaceAllString(v, "$1-(id)")
+		return arnAccount.ReplaceAllString(v, "$1(account):")
+	case map[string]any:
+		masked := map[string]any{}
+		for key, value := range v {
+			masked[key] = mask(value)
+		}
+		return masked
+	case []any:
+		masked := []any{}
+		for _, value := range v {
+			masked = append(masked, mask(value))
+		}
+		return masked
+	}
+	return value
+}
+
+// outputNames returns the names in either set of outputs, sorted
+func outputNames(expected, actual map[string]any) []string {
+	names := []string{}
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// missing marks a value that isn't there, as distinct from null
+var missing = &struct{}{}
+
+// diffValues describes each difference between two values decoded from JSON,
+// by its path from the output, e.g. vpc_cidrs["live_data"] or subnet_ids[2]
+func diffValues(path string, expected, actual any) []string {
+	switch {
+	case expected == missing:
+		return []string{fmt.Sprintf("%s: added %s", path, describe(actual))}
+	case actual == missing:
+		return []string{fmt.Sprintf("%s: removed %s", path, describe(expected))}
+	}
+
+	switch e := expected.(type) {
+	case map[string]any:
+		if a, ok := actual.(map[string]any); ok {
+			var differences []string
+			for _, key := range outputNames(e, a) {
+				differences = append(differences, diffValues(fmt.Sprintf("%s[%q]", path, key), lookup(e, key), lookup(a, key))...)
+			}
+			return differences
+		}
+	case []any:
+		if a, ok := actual.([]any); ok {
+			var differences []string
+			for i := 0; i < max(len(e), len(a)); i++ {
+				differences = append(differences, diffValues(fmt.Sprintf("%s[%d]", path, i), index(e, i), index(a, i))...)
+			}
+			return differences
+		}
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: changed from %s to %s", path, describe(expected), describe(actual))}
+}
+
+func lookup(values map[string]any, key string) any {
+	if value, ok := values[key]; ok {
+		return value
+	}
+	return missing
+}
+
+func index(values []any, i int) any {
+	if i < len(values) {
+		return values[i]
+	}
+	return missing
+}
+
+// describe renders a value as compact JSON
+func describe(value any) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
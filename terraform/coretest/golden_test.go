@@ -0,0 +1,90 @@
+package coretest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGoldenAgainstPlan(t *testing.T) {
+	outputs := LoadPlan(t, ".", "testdata/plan.json")
+
+	assert.Equal(t, "testdata/golden/core-logging.plan.json", GoldenPath("core-logging", outputs))
+	MatchGolden(t, outputs, "core-logging")
+}
+
+func TestMatchGoldenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.rules")
+	if err := os.WriteFile(path, []byte("pass tcp any any <> any 443 (sid:1;)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	MatchGoldenFile(t, path, []byte("pass tcp any any <> any 443 (sid:1;)\n"))
+}
+
+func TestDiffValues(t *testing.T) {
+	decode := func(content string) any {
+		var value any
+		if err := json.Unmarshal([]byte(content), &value); err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		diff     []string
+	}{
+		{"equal", `{"a": [1, {"b": null}]}`, `{"a": [1, {"b": null}]}`, nil},
+		{"changed", `{"live_data": "10.20.128.0/19"}`, `{"live_data": "10.20.0.0/19"}`, []string{
+			`vpc_cidrs["live_data"]: changed from "10.20.128.0/19" to "10.20.0.0/19"`,
+		}},
+		{"added and removed keys", `{"a": 1}`, `{"b": 1}`, []string{
+			`vpc_cidrs["a"]: removed 1`,
+			`vpc_cidrs["b"]: added 1`,
+		}},
+		{"list grown", `["a"]`, `["a", "b"]`, []string{`vpc_cidrs[1]: added "b"`}},
+		{"list shrunk", `["a", "b"]`, `["b"]`, []string{
+			`vpc_cidrs[0]: changed from "a" to "b"`,
+			`vpc_cidrs[1]: removed "b"`,
+		}},
+		{"type changed", `{"a": 1}`, `["a"]`, []string{`vpc_cidrs: changed from {"a":1} to ["a"]`}},
+		{"null", `null`, `{}`, []string{`vpc_cidrs: changed from null to {}`}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.diff, diffValues("vpc_cidrs", decode(test.expected), decode(test.actual)))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value  any
+		masked any
+	}{
+		{"vpc-0123456789abcdef0", "vpc-(id)"},
+		{"tgw-attach-0123abcd", "tgw-attach-(id)"},
+		{"arn:aws:network-firewall:eu-west-2:123456789012:firewall/live-data-inline-inspection", "arn:aws:network-firewall:eu-west-2:(account):firewall/live-data-inline-inspection"},
+		{map[string]any{"live_data": []any{"subnet-0123456789abcdef0", 3.0}}, map[string]any{"live_data": []any{"subnet-(id)", 3.0}}},
+		// names and CIDRs are left alone, even if they end in hex
+		{"live_data-public", "live_data-public"},
+		{"core-logging-production", "core-logging-production"},
+		{"10.20.0.0/16", "10.20.0.0/16"},
+		{"rtb-live_data-data-eu-west-2a", "rtb-live_data-data-eu-west-2a"},
+		{"hmpps-deadbeef0", "hmpps-deadbeef0"},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.masked, mask(test.value), "%v", test.value)
+	}
+}
+
+func TestDiffValuesMissingOutput(t *testing.T) {
+	assert.Equal(t, []string{`vpc_cidrs: added "10.20.0.0/16"`}, diffValues("vpc_cidrs", missing, "10.20.0.0/16"))
+	assert.Equal(t, []string{`vpc_cidrs: removed "10.20.0.0/16"`}, diffValues("vpc_cidrs", "10.20.0.0/16", missing))
+}
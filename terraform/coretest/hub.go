@@ -0,0 +1,43 @@
+package coretest
+
+import (
+	"testing"
+)
+
+// Domains are the live_data and non_live_data VPCs in every core account
+var Domains = []string{"live_data", "non_live_data"}
+
+// AvailabilityZones are the zones the VPC modules spread each subnet type over
+var AvailabilityZones = []string{"eu-west-2a", "eu-west-2b", "eu-west-2c"}
+
+// PrivateSubnetTypes are the vpc-hub subnet types with a private route table
+var PrivateSubnetTypes = []string{"private", "data", "transit-gateway"}
+
+// HubVPCChecks returns the checks for a core account with a vpc-hub VPC per
+// domain, using the outputs in the account's output.tf. The expected VPC CIDRs
+// come from the CIDR register.
+func HubVPCChecks(t *testing.T, account string) []Check {
+	routeTables := []string{}
+	for _, subnetType := range PrivateSubnetTypes {
+		for _, az := range AvailabilityZones {
+			routeTables = append(routeTables, subnetType+"-"+az)
+		}
+	}
+
+	publicRouteTables := map[string]string{}
+	igwRoutes := map[string]string{}
+	for _, domain := range Domains {
+		publicRouteTables[domain] = domain + "-public"
+		igwRoutes[domain] = "0.0.0.0/0"
+	}
+
+	return []Check{
+		MapKeySuffixes("non_live_data_private_route_tables", routeTables...),
+		MapKeySuffixes("live_data_private_route_tables", routeTables...),
+		MapEquals("public_route_tables", publicRouteTables),
+		MapEquals("public_igw_route", igwRoutes),
+		Count("tgw_subnet_ids", len(AvailabilityZones)),
+		Count("non_tgw_subnet_ids", 3*len(AvailabilityZones)),
+		MapEquals("vpc_cidrs", coreCidrs(t, account)),
+	}
+}
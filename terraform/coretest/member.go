@@ -0,0 +1,97 @@
+package coretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// MemberVPCChecks returns the checks for a core-vpc workspace, e.g.
+// core-vpc-development, against the networks defined for its tier:
+//
+//   - the subnet_sets output has each network's subnet sets
+//   - each network's VPC has one transit gateway attachment
+//   - each VPC has an endpoint for every additional_endpoints entry
+//   - each subnet set has a resource share, and each network a member-delegation role
+//
+// All but the first need -plan.
+func MemberVPCChecks(t *testing.T, workspace string) []Check {
+	tier := strings.TrimPrefix(workspace, "core-vpc-")
+	tierNetworks := []Network{}
+	for _, network := range networks(t) {
+		if network.Tier() == tier {
+			tierNetworks = append(tierNetworks, network)
+		}
+	}
+	if len(tierNetworks) == 0 {
+		t.Fatalf("no networks in %s for %s", NetworksDir, workspace)
+	}
+	return memberVPCChecks(tierNetworks)
+}
+
+func memberVPCChecks(networks []Network) []Check {
+	subnetSets := map[string][]string{}
+	for _, network := range networks {
+		subnetSets[network.Name] = network.SubnetSetNames()
+	}
+	checks := []Check{JSONEquals("subnet_sets", subnetSets)}
+
+	for _, network := range networks {
+		vpc := fmt.Sprintf("module.vpc[%q]", network.Name)
+		checks = append(checks, ModuleResourceCount(vpc, "aws_ec2_transit_gateway_vpc_attachment", 1))
+
+		if endpoints := network.Options.AdditionalEndpoints; len(endpoints) > 0 {
+			checks = append(checks, ModuleAttributeValues(vpc, "aws_vpc_endpoint", "service_name", endpoints...))
+		}
+
+		for _, set := range network.SubnetSetNames() {
+			checks = append(checks, ModuleResourceCount(fmt.Sprintf("module.resource-share[%q]", network.Name+"-"+set), "aws_ram_resource_share", 1))
+		}
+		checks = append(checks, ResourceExists(fmt.Sprintf("aws_iam_role.member-delegation[%q]", network.Name)))
+	}
+	return checks
+}
+
+// MemberDelegationChecks returns the checks for a member-bootstrap workspace,
+// i.e. a member account such as nomis-development: the account's GitHub OIDC
+// role can assume the member-delegation role of each network whose subnet
+// sets list the account (the oidc_assumable_role_arns output).
+func MemberDelegationChecks(t *testing.T, workspace string) []Check {
+	return memberDelegationChecks(networks(t), workspace)
+}
+
+func memberDelegationChecks(networks []Network, account string) []Check {
+	return []Check{{"oidc_assumable_role_arns", func(t *testing.T, outputs Outputs) {
+		var arns []string
+		if err := json.Unmarshal([]byte(outputs.JSON(t, "oidc_assumable_role_arns")), &arns); err != nil {
+			t.Fatalf("oidc_assumable_role_arns: %s", err)
+		}
+		if len(arns) == 0 {
+			t.Skipf("%s has no GitHub OIDC role", account)
+		}
+		roles := []string{}
+		for _, arn := range arns {
+			if _, role, ok := strings.Cut(arn, ":role/"); ok {
+				roles = append(roles, role)
+			}
+		}
+
+		found := false
+		for _, network := range networks {
+			for _, set := range network.SubnetSetNames() {
+				if !slices.Contains(network.Cidr.SubnetSets[set].Accounts, account) {
+					continue
+				}
+				found = true
+				if role := "member-delegation-" + network.Name; !slices.Contains(roles, role) {
+					t.Errorf("%s is in %s subnet set %s, expected its GitHub OIDC role to be able to assume %s, got %v", account, network.Name, set, role, roles)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s is not in any subnet set in %s", account, NetworksDir)
+		}
+	}}}
+}
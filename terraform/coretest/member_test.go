@@ -0,0 +1,53 @@
+package coretest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadNetworks(t *testing.T) {
+	networks, err := LoadNetworks("testdata/environments-networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, networks, 1) {
+		assert.Equal(t, "house-sandbox", networks[0].Name)
+		assert.Equal(t, "sandbox", networks[0].Tier())
+		assert.Equal(t, []string{"general"}, networks[0].SubnetSetNames())
+		assert.True(t, networks[0].Options.BastionLinux)
+	}
+}
+
+func TestNetworksCoverCoreVPCTiers(t *testing.T) {
+	tiers := map[string]bool{}
+	for _, network := range networks(t) {
+		tiers[network.Tier()] = true
+	}
+	for _, tier := range []string{"development", "test", "preproduction", "production", "sandbox"} {
+		assert.True(t, tiers[tier], "no networks for core-vpc-%s", tier)
+	}
+}
+
+func TestMemberVPCChecksAgainstPlan(t *testing.T) {
+	// the fixture has the shape of a core-vpc-sandbox plan, cut down to one network
+	networks, err := LoadNetworks("testdata/environments-networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := LoadPlan(t, ".", "testdata/core-vpc-plan.json")
+
+	Run(t, outputs, memberVPCChecks(networks))
+}
+
+func TestMemberDelegationChecksAgainstPlan(t *testing.T) {
+	// cooker has development accounts in the house-sandbox network
+	networks, err := LoadNetworks("testdata/environments-networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := LoadPlan(t, ".", "testdata/member-bootstrap-plan.json")
+
+	Run(t, outputs, memberDelegationChecks(networks, "cooker-development"))
+}
@@ -0,0 +1,111 @@
+package coretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// NetworksDir holds a definition per business unit network, named <business-unit>-<tier>.json
+const NetworksDir = "environments-networks"
+
+// Network is a business unit network definition, cut down to what the core
+// stacks build from it
+type Network struct {
+	Name string
+	Cidr struct {
+		SubnetSets map[string]struct {
+			Cidr     string   `json:"cidr"`
+			Accounts []string `json:"accounts"`
+		} `json:"subnet_sets"`
+	} `json:"cidr"`
+	Options struct {
+		AdditionalEndpoints []string `json:"additional_endpoints"`
+		BastionLinux        bool     `json:"bastion_linux"`
+	} `json:"options"`
+}
+
+// SubnetSetNames returns the network's subnet set names, sorted
+func (n Network) SubnetSetNames() []string {
+	names := []string{}
+	for name := range n.Cidr.SubnetSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tier is the core-vpc account the network is in, from its name, e.g. development
+func (n Network) Tier() string {
+	return n.Name[strings.LastIndex(n.Name, "-")+1:]
+}
+
+// FindNetworks walks up from the working directory to the network definitions
+func FindNetworks() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, NetworksDir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found above the working directory", NetworksDir)
+		}
+		dir = parent
+	}
+}
+
+// LoadNetworks reads every network definition in dir, sorted by name
+func LoadNetworks(dir string) ([]Network, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	networks := []Network{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		network := Network{Name: strings.TrimSuffix(filepath.Base(file), ".json")}
+		if err := json.Unmarshal(content, &network); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// networks finds and loads the network definitions, failing the test if it can't
+func networks(t *testing.T) []Network {
+	t.Helper()
+	dir, err := FindNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadNetworks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+// Workspace returns the stack's Terraform workspace: TF_WORKSPACE if it's set,
+// as it needs to be with a -plan fixture, otherwise the workspace selected in dir
+func Workspace(t *testing.T, dir string) string {
+	if workspace := os.Getenv("TF_WORKSPACE"); workspace != "" {
+		return workspace
+	}
+	return strings.TrimSpace(terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: dir, NoColor: true}, "workspace", "show"))
+}
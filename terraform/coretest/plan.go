@@ -0,0 +1,133 @@
+package coretest
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+var planFlag = flag.String("plan", "", "assert against a saved plan, or the `terraform show -json` output of one, instead of refreshing the stack")
+
+// Load returns the outputs of the stack in dir. With -plan they come from a
+// saved plan, which needs no AWS credentials; otherwise the stack is refreshed.
+func Load(t *testing.T, dir string) Outputs {
+	if *planFlag == "" {
+		return Refresh(t, dir)
+	}
+	return LoadPlan(t, dir, *planFlag)
+}
+
+// planOutputs reads outputs and resources from a plan
+type planOutputs struct {
+	plan *terraform.PlanStruct
+}
+
+// LoadPlan reads a plan for the stack in dir. A .json file is read as
+// `terraform show -json` output, such as a recorded fixture; anything else is
+// a binary plan from `terraform plan -out`, shown with the stack's providers.
+// Relative paths are relative to the test's working directory.
+func LoadPlan(t *testing.T, dir, path string) Outputs {
+	t.Helper()
+
+	var plan *terraform.PlanStruct
+	if strings.HasSuffix(path, ".json") {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan, err = terraform.ParsePlanJSON(string(content)); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+	} else {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan = terraform.ShowWithStruct(t, &terraform.Options{TerraformDir: dir, PlanFilePath: absolute})
+	}
+	return planOutputs{plan}
+}
+
+func (p planOutputs) value(t *testing.T, name string) any {
+	t.Helper()
+	if p.plan.RawPlan.PlannedValues == nil {
+		t.Fatalf("the plan has no planned values")
+	}
+	output, ok := p.plan.RawPlan.PlannedValues.Outputs[name]
+	if !ok || output.Value == nil {
+		t.Fatalf("output %s is not in the plan, or is not known until apply", name)
+	}
+	return output.Value
+}
+
+func (p planOutputs) String(t *testing.T, name string) string {
+	return format(p.value(t, name))
+}
+
+func (p planOutputs) Map(t *testing.T, name string) map[string]string {
+	values, ok := p.value(t, name).(map[string]any)
+	if !ok {
+		t.Fatalf("output %s is not a map", name)
+	}
+	result := map[string]string{}
+	for key, value := range values {
+		result[key] = format(value)
+	}
+	return result
+}
+
+func (p planOutputs) JSON(t *testing.T, name string) string {
+	content, err := json.Marshal(p.value(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func (p planOutputs) All(t *testing.T) map[string]any {
+	if p.plan.RawPlan.PlannedValues == nil {
+		t.Fatalf("the plan has no planned values")
+	}
+	values := map[string]any{}
+	for name, output := range p.plan.RawPlan.PlannedValues.Outputs {
+		switch {
+		case output.Sensitive:
+			values[name] = SensitiveValue
+		case output.Value == nil:
+			values[name] = KnownAfterApply
+		default:
+			values[name] = output.Value
+		}
+	}
+	return values
+}
+
+// Resources returns the planned resources by address
+func (p planOutputs) Resources(t *testing.T) map[string]*tfjson.StateResource {
+	return p.plan.ResourcePlannedValuesMap
+}
+
+// PlannedResources is implemented by outputs read from a plan
+type PlannedResources interface {
+	Resources(t *testing.T) map[string]*tfjson.StateResource
+}
+
+// format renders a value the way `terraform output` does for strings and numbers
+func format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprint(v)
+	default:
+		content, _ := json.Marshal(v)
+		return string(content)
+	}
+}
@@ -0,0 +1,22 @@
+package coretest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPlan(t *testing.T) {
+	outputs := LoadPlan(t, ".", "testdata/plan.json")
+
+	assert.Equal(t, "3", outputs.String(t, "tgw_subnet_ids"))
+	assert.Equal(t, map[string]string{"live_data": "0.0.0.0/0", "non_live_data": "0.0.0.0/0"}, outputs.Map(t, "public_igw_route"))
+	assert.JSONEq(t, `{"live_data": "10.20.128.0/19", "non_live_data": "10.20.160.0/19"}`, outputs.JSON(t, "vpc_cidrs"))
+}
+
+func TestHubVPCChecksAgainstPlan(t *testing.T) {
+	// the fixture has the shape of a core-logging plan, cut down to its outputs and subnets
+	outputs := LoadPlan(t, ".", "testdata/plan.json")
+
+	Run(t, outputs, append(HubVPCChecks(t, "core-logging"), ResourceCount("aws_subnet", 24)))
+}
@@ -0,0 +1,117 @@
+package coretest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RegisterFile is the CIDR allocation register at the root of the repository
+const RegisterFile = "cidr-allocation.md"
+
+// CoreSection is the heading of the register's table of core account CIDRs
+const CoreSection = "## Core Accounts CIDRs"
+
+// Register maps each allocation in the register's core account table, e.g.
+// "core-logging live_data", to its CIDR
+type Register map[string]string
+
+// LoadRegister reads the core account table in the register at path. The
+// member subnet set tables are skipped, as they reuse core account names for
+// other allocations.
+func LoadRegister(path string) (Register, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	register := Register{}
+	inCore := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			inCore = line == CoreSection
+			continue
+		}
+		if !inCore {
+			continue
+		}
+		// | 10.20.128.0 | /19  | core-logging live_data |
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		if len(cells) < 3 {
+			continue
+		}
+		address := strings.TrimSpace(cells[0])
+		mask := strings.TrimSpace(cells[1])
+		allocation := strings.TrimSpace(cells[2])
+		if !strings.HasPrefix(mask, "/") || allocation == "" || allocation == "-" {
+			continue
+		}
+		if cidr, ok := register[allocation]; ok {
+			return nil, fmt.Errorf("%s: %s is allocated twice, %s and %s", path, allocation, cidr, address+mask)
+		}
+		register[allocation] = address + mask
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(register) == 0 {
+		return nil, fmt.Errorf("%s: no %q table", path, CoreSection)
+	}
+	return register, nil
+}
+
+// FindRegister walks up from the working directory to the register
+func FindRegister() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, RegisterFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found above the working directory", RegisterFile)
+		}
+		dir = parent
+	}
+}
+
+// CoreCidrs returns the live_data and non_live_data CIDRs allocated to a core account
+func (r Register) CoreCidrs(account string) (map[string]string, error) {
+	cidrs := map[string]string{}
+	for _, domain := range Domains {
+		cidr, ok := r[account+" "+domain]
+		if !ok {
+			return nil, fmt.Errorf("%s has no %s allocation in %s", account, domain, RegisterFile)
+		}
+		cidrs[domain] = cidr
+	}
+	return cidrs, nil
+}
+
+// coreCidrs loads the register and returns account's CIDRs, failing the test if it can't
+func coreCidrs(t *testing.T, account string) map[string]string {
+	t.Helper()
+	path, err := FindRegister()
+	if err != nil {
+		t.Fatal(err)
+	}
+	register, err := LoadRegister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cidrs, err := register.CoreCidrs(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cidrs
+}
@@ -0,0 +1,72 @@
+package coretest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRegister(t *testing.T) {
+	register, err := LoadRegister("testdata/cidr-allocation.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotContains(t, register, "used for vpcs in core accounts")
+	assert.NotContains(t, register, "-")
+	assert.Equal(t, "10.20.128.0/19", register["core-logging live_data"], "member subnet set rows are ignored")
+
+	cidrs, err := register.CoreCidrs("core-logging")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"live_data": "10.20.128.0/19", "non_live_data": "10.20.160.0/19"}, cidrs)
+
+	_, err = register.CoreCidrs("core-security")
+	assert.Error(t, err, "core-security has no non_live_data allocation")
+}
+
+func TestRegisterCoversCoreAccounts(t *testing.T) {
+	path, err := FindRegister()
+	if err != nil {
+		t.Fatal(err)
+	}
+	register, err := LoadRegister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[string]string{
+		"core-network-services": {"live_data": "10.20.0.0/19", "non_live_data": "10.20.32.0/19"},
+		"core-shared-services":  {"live_data": "10.20.64.0/19", "non_live_data": "10.20.96.0/19"},
+		"core-logging":          {"live_data": "10.20.128.0/19", "non_live_data": "10.20.160.0/19"},
+		"core-security":         {"live_data": "10.20.192.0/20", "non_live_data": "10.20.208.0/20"},
+	}
+	for account, cidrs := range expected {
+		actual, err := register.CoreCidrs(account)
+		assert.NoError(t, err)
+		assert.Equal(t, cidrs, actual, account)
+	}
+}
+
+func TestLoadRegisterDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RegisterFile)
+	content := CoreSection + `
+
+| CIDR        | mask | allocated to           |
+|:------------|:-----|:-----------------------|
+| 10.20.128.0 | /19  | core-logging live_data |
+| 10.20.0.0   | /19  | core-logging live_data |
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadRegister(path)
+	assert.ErrorContains(t, err, "core-logging live_data is allocated twice, 10.20.128.0/19 and 10.20.0.0/19")
+}
+
+func TestHasKeySuffix(t *testing.T) {
+	values := map[string]string{"live_data-private-eu-west-2a": "rtb-1"}
+	assert.True(t, hasKeySuffix(values, "private-eu-west-2a"))
+	assert.False(t, hasKeySuffix(values, "data-eu-west-2a"))
+}
@@ -0,0 +1,8 @@
+output "oidc_assumable_role_arns" {
+  description = "The roles the account's GitHub OIDC role can assume, empty if the account has no GitHub OIDC role"
+  value = flatten([
+    for document in data.aws_iam_policy_document.oidc_assume_role_member : [
+      for statement in document.statement : statement.resources if statement.sid == "AllowOIDCToAssumeRoles"
+    ]
+  ])
+}
@@ -0,0 +1,13 @@
+package test
+
+import (
+	"testing"
+
+	"modernisation-platform/coretest"
+)
+
+func TestMemberDelegation(t *testing.T) {
+	outputs := coretest.Load(t, "../")
+
+	coretest.Run(t, outputs, coretest.MemberDelegationChecks(t, coretest.Workspace(t, "../")))
+}
@@ -79,4 +79,32 @@ output "inspection_default_routes" {
     live_data     = { for key, value in data.aws_route.live_data : key => value.destination_cidr_block }
     non_live_data = { for key, value in data.aws_route.non_live_data : key => value.destination_cidr_block }
   }
-}
\ No newline at end of file
+}
+
+output "inspection_route_targets" {
+  value = {
+    for vpc_key, tables in { live_data = data.aws_route_table.live_data, non_live_data = data.aws_route_table.non_live_data } : vpc_key => {
+      firewall_endpoints = {
+        for state in module.vpc_inspection[vpc_key].firewall.firewall_status[0].sync_states :
+        state.availability_zone => state.attachment[0].endpoint_id
+      }
+      nat_gateways = {
+        for name, gateway in module.vpc_inspection[vpc_key].nat_gateway :
+        trimprefix(name, "public-") => gateway.id
+      }
+      internet_gateway = module.vpc_inspection[vpc_key].internet_gateway.id
+      transit_gateway  = aws_ec2_transit_gateway.transit-gateway.id
+      # Only IPv4 routes to a firewall endpoint, NAT gateway, transit gateway
+      # or internet gateway, the targets the vpc-inspection module creates.
+      # Anything else, such as an IPv6 or prefix list route, has no
+      # cidr_block to key on.
+      routes = {
+        for name, table in tables : name => {
+          for route in table.routes :
+          route.cidr_block => one(compact([route.vpc_endpoint_id, route.nat_gateway_id, route.transit_gateway_id, route.gateway_id]))
+          if route.cidr_block != null && route.cidr_block != "" && length(compact([route.vpc_endpoint_id, route.nat_gateway_id, route.transit_gateway_id, route.gateway_id])) == 1
+        }
+      }
+    }
+  }
+}
@@ -0,0 +1,257 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"modernisation-platform/coretest"
+	"modernisation-platform/definitions/firewall"
+	"modernisation-platform/definitions/repo"
+)
+
+// These tests load the firewall rules with the definitions tool's firewall
+// package, which templates them the same way locals.tf does, so they run
+// without AWS credentials or Terraform.
+
+// liveRuleFiles hold rules for traffic from live_data VPCs
+var liveRuleFiles = map[string]bool{
+	"preproduction_rules.json": true,
+	"production_rules.json":    true,
+	"live_data_rules.json":     true,
+}
+
+// internetEgressFromLive are the reviewed live_data rules allowed to reach any
+// destination on a single port
+var internetEgressFromLive = map[string]bool{
+	"mp_hmpps_preproduction_to_saas_agent_tcp": true,
+	"mp_hmpps_preproduction_to_saas_agent_udp": true,
+	"mp_hmpps_production_to_saas_agent_tcp":    true,
+	"mp_hmpps_production_to_saas_agent_udp":    true,
+}
+
+// knownDuplicates are existing duplicate rules, by name, still to be fixed.
+// dom1_dcs_to_planetfm_preproduction_464_Kerberos_TCP has protocol UDP,
+// where the production rule of the same name has TCP.
+var knownDuplicates = map[string]bool{
+	"dom1_dcs_to_planetfm_preproduction_464_Kerberos_UDP": true,
+}
+
+// unallocatedLiterals are existing literal addresses that aren't inside a
+// named range, still to be given one. 10.0.0.0/8 is allowed to the
+// data-insights-hub and PPUD rules, and 10.172.68.0/23 is the DOM1 NAS, next
+// to dom1-domain-controllers. 1.1.1.1 and 1.0.0.1 are in IP_SET_EXAMPLE, which
+// no rule uses.
+var unallocatedLiterals = map[string]bool{
+	"10.0.0.0/8":     true,
+	"10.172.68.0/23": true,
+	"1.1.1.1/32":     true,
+	"1.0.0.1/32":     true,
+}
+
+var anyPrefix = firewall.Any
+
+// loadFirewallRules loads the rule files from the working tree
+func loadFirewallRules(t *testing.T) firewall.RuleSet {
+	t.Helper()
+	root, err := repo.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := firewall.Load(repo.WorkTree{Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+// resolvePolicies resolves every rule in each policy, by policy name, in sid order
+func resolvePolicies(t *testing.T, rules firewall.RuleSet) map[string][]firewall.Resolved {
+	t.Helper()
+	policies := map[string][]firewall.Resolved{}
+	for _, policy := range firewall.Policies {
+		resolved, err := rules.Context(policy.Name)
+		if err != nil {
+			t.Fatalf("%s policy: %s", policy.Name, err)
+		}
+		policies[policy.Name] = resolved
+	}
+	return policies
+}
+
+func TestFirewallRulesLint(t *testing.T) {
+	// references that don't resolve, names defined in more than one rule
+	// file (the per-tier files are merged into one map, so a repeated name
+	// silently replaces a rule), and unknown actions or protocols
+	failures, warnings := firewall.Lint(loadFirewallRules(t))
+	for _, failure := range failures {
+		t.Error(failure)
+	}
+	for _, warning := range warnings {
+		t.Log(warning)
+	}
+}
+
+func TestFirewallRulesResolve(t *testing.T) {
+	for name, rules := range resolvePolicies(t, loadFirewallRules(t)) {
+		assert.NotEmpty(t, rules, "the %s policy has no rules", name)
+		for _, rule := range rules {
+			assert.Contains(t, []string{"IP", "TCP", "UDP", "ICMP"}, rule.Protocol, "%s has a protocol the rules don't use", rule)
+		}
+	}
+}
+
+// privateRanges are the RFC1918 blocks
+var privateRanges = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+}
+
+// literalAddresses returns the addresses written directly in the rule files
+// and sets.json, rather than as a ${name} range or $NAME set, with where each
+// one is used
+func literalAddresses(t *testing.T) map[string][]string {
+	t.Helper()
+	root, err := repo.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := repo.WorkTree{Root: root}
+	read := func(file string, v any) {
+		content, err := src.ReadFile(firewall.Dir + "/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(content, v); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+	}
+
+	literals := map[string][]string{}
+	add := func(value, usedBy string) {
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "$") || strings.EqualFold(value, "ANY") {
+			return
+		}
+		literals[value] = append(literals[value], usedBy)
+	}
+	for _, policy := range firewall.Policies {
+		for _, file := range policy.Files {
+			var rules map[string]firewall.Rule
+			read(file, &rules)
+			for name, rule := range rules {
+				add(rule.SourceIP, file+" "+name)
+				add(rule.DestinationIP, file+" "+name)
+			}
+		}
+	}
+	var sets firewall.Sets
+	read("sets.json", &sets)
+	for name, values := range sets.IPSets {
+		for _, value := range values {
+			add(value, "sets.json $"+name)
+		}
+	}
+	return literals
+}
+
+func TestFirewallCidrsAreAllocated(t *testing.T) {
+	// literal CIDRs in the rules and sets should be inside a named range in
+	// cidr-ranges.tf, so every address the firewall allows has an owner. A
+	// range covering a whole RFC1918 block, such as mojo-end-user-devices at
+	// 10.0.0.0/8, doesn't count as an owner.
+	rules := loadFirewallRules(t)
+	owners := []netip.Prefix{}
+	for name, cidr := range rules.Ranges {
+		prefix, err := netip.ParsePrefix(cidr)
+		if !assert.NoError(t, err, "cidr-ranges.tf: %s", name) {
+			continue
+		}
+		if !slices.ContainsFunc(privateRanges, func(p netip.Prefix) bool { return prefix.Bits() <= p.Bits() && prefix.Contains(p.Addr()) }) {
+			owners = append(owners, prefix)
+		}
+	}
+
+	for value, usedBy := range literalAddresses(t) {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if !assert.NoError(t, addrErr, "%v: invalid address %q", usedBy, value) {
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefix = prefix.Masked()
+		if prefix == anyPrefix || unallocatedLiterals[value] {
+			continue
+		}
+		allocated := slices.ContainsFunc(owners, func(k netip.Prefix) bool {
+			return k.Contains(prefix.Addr()) && k.Bits() <= prefix.Bits()
+		})
+		assert.True(t, allocated, "%v uses %s, which isn't inside any range in cidr-ranges.tf", usedBy, value)
+	}
+}
+
+func TestFirewallRulesAreNotTooBroad(t *testing.T) {
+	for _, policy := range resolvePolicies(t, loadFirewallRules(t)) {
+		for _, rule := range policy {
+			if rule.Action != "PASS" {
+				continue
+			}
+			// only 0.0.0.0/0 itself covers every address
+			anySource := slices.Contains(rule.Sources, anyPrefix)
+			anyDestination := slices.Contains(rule.Destinations, anyPrefix)
+			assert.False(t, anySource && anyDestination, "%s allows any source to any destination", rule)
+			if liveRuleFiles[rule.File] && anyDestination {
+				assert.True(t, internetEgressFromLive[rule.Name], "%s allows live_data traffic to 0.0.0.0/0", rule)
+			}
+		}
+	}
+}
+
+func TestFirewallRulesAreNotShadowed(t *testing.T) {
+	// with DEFAULT_ACTION_ORDER every PASS rule is evaluated before any DROP
+	// rule, so a DROP rule covered by a PASS rule never applies. PASS rules
+	// covered by a broader PASS rule are logged rather than failed, as they
+	// often record a specific flow that was asked for.
+	for _, rules := range resolvePolicies(t, loadFirewallRules(t)) {
+		for i, rule := range rules {
+			for j, other := range rules {
+				if i == j || other.Action != "PASS" || !other.Covers(rule) {
+					continue
+				}
+				switch {
+				case rule.Action == "DROP":
+					t.Errorf("%s never applies, as %s passes the same traffic first", rule, other)
+				case rule.Action == "PASS" && rule.Covers(other):
+					if i < j && !knownDuplicates[other.Name] {
+						t.Errorf("%s duplicates %s", other, rule)
+					}
+				case rule.Action == "PASS":
+					t.Logf("%s is already allowed by %s", rule, other)
+				}
+			}
+		}
+	}
+}
+
+// TestFirewallSuricata keeps each policy's rules, rendered as Suricata rules,
+// in testdata/suricata, so a change to the rule files shows up in the same
+// pull request as a diff of the rules the firewall will run
+func TestFirewallSuricata(t *testing.T) {
+	rules := loadFirewallRules(t)
+	for _, policy := range firewall.Policies {
+		var b bytes.Buffer
+		if err := rules.WriteSuricata(&b, policy); err != nil {
+			t.Fatal(err)
+		}
+		coretest.MatchGoldenFile(t, filepath.Join("testdata", "suricata", policy.Name+".rules"), b.Bytes())
+	}
+}
@@ -6,7 +6,11 @@ toolchain go1.24.1
 
 require (
 	github.com/gruntwork-io/terratest v0.49.0
+	github.com/hashicorp/hcl/v2 v2.22.0
 	github.com/stretchr/testify v1.10.0
+	github.com/zclconf/go-cty v1.15.0
+	modernisation-platform/coretest v0.0.0
+	modernisation-platform/definitions v0.0.0
 )
 
 require (
@@ -20,7 +24,6 @@ require (
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/hashicorp/go-safetemp v1.0.0 // indirect
 	github.com/hashicorp/go-version v1.7.0 // indirect
-	github.com/hashicorp/hcl/v2 v2.22.0 // indirect
 	github.com/hashicorp/terraform-json v0.23.0 // indirect
 	github.com/jinzhu/copier v0.0.0-20190924061706-b57f9002281a // indirect
 	github.com/klauspost/compress v1.16.5 // indirect
@@ -33,7 +36,6 @@ require (
 	github.com/rogpeppe/go-internal v1.10.0 // indirect
 	github.com/tmccombs/hcl2json v0.6.4 // indirect
 	github.com/ulikunitz/xz v0.5.10 // indirect
-	github.com/zclconf/go-cty v1.15.0 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/mod v0.18.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
@@ -44,3 +46,7 @@ require (
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace modernisation-platform/coretest => ../../../coretest
+
+replace modernisation-platform/definitions => ../../../../scripts/internal/definitions
@@ -1,30 +1,28 @@
 package test
 
 import (
-    "encoding/json"
-	"testing"
+	"encoding/json"
 	"regexp"
+	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+
+	"modernisation-platform/coretest"
 )
 
 func TestTransitGateway(t *testing.T) {
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
-		TerraformDir: "../",
-	})
-
-	terraform.RunTerraformCommand(t, terraformOptions, "refresh")
-	terraform.Plan(t, terraformOptions)
+	outputs := coretest.Load(t, "../")
 
 	//Test transit-gateway will not be affected
-	output2 := terraform.Output(t, terraformOptions, "transit_gateway")
+	output2 := outputs.String(t, "transit_gateway")
 	assert.Equal(t, output2, "64589")
 
 	//Check the transit gateway ram share is created
-	output6 := terraform.Output(t, terraformOptions, "transit_gateway_ram_share")
+	output6 := outputs.String(t, "transit_gateway_ram_share")
 	assert.Equal(t, output6, "transit-gateway")
 
+	coretest.Run(t, outputs, []coretest.Check{coretest.Golden(coretest.Workspace(t, "../"))})
 }
 
 func TestInspectionVPCs(t *testing.T) {
@@ -81,7 +79,7 @@ func TestInspectionVPCs(t *testing.T) {
 
 	// Assert that each VPC has all of its NAT Gateways
 	assert.Equal(t, natGWs["non_live_data"], "3")
-	assert.Equal(t, natGWs["non_live_data"], "3")
+	assert.Equal(t, natGWs["live_data"], "3")
 
 	// Run `terraform output` to get the value of the output variable as a string
 	terraformOutput := terraform.OutputJson(t, terraformOptions, "inspection_default_routes")
@@ -107,4 +105,11 @@ func TestInspectionVPCs(t *testing.T) {
 	for _, value := range nonLiveDataRoutes {
 		assert.Equal(t, "0.0.0.0/0", value)
 	}
-}
\ No newline at end of file
+
+	// Check each route table routes to the right target in its own AZ
+	var routeTargets map[string]inspectionRoutes
+	if err := json.Unmarshal([]byte(terraform.OutputJson(t, terraformOptions, "inspection_route_targets")), &routeTargets); err != nil {
+		t.Fatalf("Failed to parse inspection_route_targets output as JSON: %s", err)
+	}
+	checkInspectionRoutes(t, routeTargets)
+}
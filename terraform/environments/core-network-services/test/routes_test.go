@@ -0,0 +1,352 @@
+package test
+
+import (
+	"encoding/json"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// These tests read the vpc-inspection module's routes from its source, so they
+// run without AWS credentials or Terraform. TestInspectionVPCs checks the same
+// expectations against the deployed route tables.
+
+const inspectionModule = "../../../modules/vpc-inspection/main.tf"
+
+// routeTarget is what a route sends traffic to
+type routeTarget string
+
+const (
+	firewallEndpoint routeTarget = "firewall endpoint"
+	natGateway       routeTarget = "NAT gateway"
+	transitGateway   routeTarget = "transit gateway"
+	internetGateway  routeTarget = "internet gateway"
+)
+
+// routeTargetAttributes maps an aws_route target argument to the target it names
+var routeTargetAttributes = map[string]routeTarget{
+	"vpc_endpoint_id":    firewallEndpoint,
+	"nat_gateway_id":     natGateway,
+	"transit_gateway_id": transitGateway,
+	"gateway_id":         internetGateway,
+}
+
+// inspectionRouteTargets are, for each subnet tier in an inspection VPC, where
+// its default route goes and where traffic back to member VPCs goes. Traffic
+// from the transit gateway is inspected before it leaves through a NAT
+// gateway, and replies from the internet are inspected on the way back in.
+var inspectionRouteTargets = map[string]struct {
+	Default routeTarget
+	Members routeTarget
+}{
+	"transit-gateway": {Default: firewallEndpoint, Members: transitGateway},
+	"inspection":      {Default: natGateway, Members: transitGateway},
+	"public":          {Default: internetGateway, Members: firewallEndpoint},
+}
+
+var (
+	// coreNetworkingCidr matches the live_data and non_live_data CIDRs in a core account's vpc.tf
+	coreNetworkingCidr = regexp.MustCompile(`(?m)^\s*(?:live_data|non_live_data)\s*=\s*"([0-9.]+/[0-9]+)"`)
+	// inspectionRouteTable splits a route table name, e.g. transit-gateway-eu-west-2a, into its tier and AZ
+	inspectionRouteTable = regexp.MustCompile(`^(transit-gateway|inspection|public)-(.+)$`)
+)
+
+// moduleRoute is an aws_route resource in the vpc-inspection module
+type moduleRoute struct {
+	Resource    string
+	Table       string
+	Destination string
+	Target      routeTarget
+	// Expression is the value of the target argument
+	Expression string
+}
+
+func loadModuleRoutes(t *testing.T) []moduleRoute {
+	content, err := os.ReadFile(inspectionModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclsyntax.ParseConfig(content, inspectionModule, hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	routes := []moduleRoute{}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "resource" || len(block.Labels) != 2 || block.Labels[0] != "aws_route" {
+			continue
+		}
+		route := moduleRoute{Resource: block.Labels[1]}
+
+		// for_each = aws_route_table.<table>
+		if attr, ok := block.Body.Attributes["for_each"]; ok {
+			if traversal, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() && len(traversal) == 2 && traversal.RootName() == "aws_route_table" {
+				route.Table = traversal[1].(hcl.TraverseAttr).Name
+			}
+		}
+		if attr, ok := block.Body.Attributes["destination_cidr_block"]; ok {
+			if value, diags := attr.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String {
+				route.Destination = value.AsString()
+			}
+		}
+		targets := 0
+		for name, target := range routeTargetAttributes {
+			if attr, ok := block.Body.Attributes[name]; ok {
+				targets++
+				route.Target = target
+				route.Expression = string(attr.Expr.Range().SliceBytes(content))
+			}
+		}
+
+		if route.Table == "" || route.Destination == "" || targets != 1 {
+			t.Fatalf("aws_route.%s: expected for_each over a route table, a literal destination_cidr_block and one target", route.Resource)
+		}
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		t.Fatalf("no aws_route resources in %s", inspectionModule)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Resource < routes[j].Resource })
+	return routes
+}
+
+// memberDestinations returns the non-default destinations routed by the module's route tables
+func memberDestinations(routes []moduleRoute) []string {
+	destinations := map[string]bool{}
+	for _, route := range routes {
+		if route.Destination != anyPrefix.String() {
+			destinations[route.Destination] = true
+		}
+	}
+	sorted := []string{}
+	for destination := range destinations {
+		sorted = append(sorted, destination)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// memberCidrs returns every subnet set in environments-networks and the other
+// core accounts' VPCs, all of which route to the internet through inspection
+func memberCidrs(t *testing.T) map[string]netip.Prefix {
+	cidrs := map[string]netip.Prefix{}
+
+	files, err := filepath.Glob("../../../../environments-networks/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var network struct {
+			Cidr struct {
+				SubnetSets map[string]struct {
+					Cidr string `json:"cidr"`
+				} `json:"subnet_sets"`
+			} `json:"cidr"`
+		}
+		if err := json.Unmarshal(content, &network); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		for name, set := range network.Cidr.SubnetSets {
+			prefix, err := netip.ParsePrefix(set.Cidr)
+			if err != nil {
+				t.Fatalf("%s: subnet set %s: %s", file, name, err)
+			}
+			cidrs[strings.TrimSuffix(filepath.Base(file), ".json")+"/"+name] = prefix
+		}
+	}
+
+	files, err = filepath.Glob("../../core-*/vpc.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		account := filepath.Base(filepath.Dir(file))
+		if account == "core-network-services" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range coreNetworkingCidr.FindAllStringSubmatch(string(content), -1) {
+			cidrs[account+"/"+match[1]] = netip.MustParsePrefix(match[1])
+		}
+	}
+	return cidrs
+}
+
+// routedBy returns the destination that routes traffic for cidr, if any
+func routedBy(cidr netip.Prefix, destinations []string) (string, bool) {
+	for _, destination := range destinations {
+		prefix, err := netip.ParsePrefix(destination)
+		if err == nil && prefix.Bits() <= cidr.Bits() && prefix.Contains(cidr.Addr()) {
+			return destination, true
+		}
+	}
+	return "", false
+}
+
+func TestInspectionRouteTargets(t *testing.T) {
+	routes := loadModuleRoutes(t)
+	members := memberDestinations(routes)
+
+	byTable := map[string]map[string]moduleRoute{}
+	for _, route := range routes {
+		expected, ok := inspectionRouteTargets[route.Table]
+		if !ok {
+			t.Errorf("aws_route.%s is in unexpected route table %s", route.Resource, route.Table)
+			continue
+		}
+		if byTable[route.Table] == nil {
+			byTable[route.Table] = map[string]moduleRoute{}
+		}
+		if existing, ok := byTable[route.Table][route.Destination]; ok {
+			t.Errorf("aws_route.%s and aws_route.%s both route %s from %s", existing.Resource, route.Resource, route.Destination, route.Table)
+		}
+		byTable[route.Table][route.Destination] = route
+
+		want := expected.Members
+		if route.Destination == anyPrefix.String() {
+			want = expected.Default
+		}
+		assert.Equal(t, want, route.Target, "aws_route.%s (%s from %s)", route.Resource, route.Destination, route.Table)
+
+		// Routes through a firewall endpoint or NAT gateway must use the one in the route table's own AZ
+		switch route.Target {
+		case firewallEndpoint:
+			assert.Equal(t, "local.firewall_endpoint_map[aws_subnet."+route.Table+"[each.key].availability_zone]", route.Expression,
+				"aws_route.%s should use the firewall endpoint in its own AZ", route.Resource)
+		case natGateway:
+			assert.Equal(t, `aws_nat_gateway.public[replace(each.key, "`+route.Table+`", "public")].id`, route.Expression,
+				"aws_route.%s should use the NAT gateway in its own AZ", route.Resource)
+		}
+	}
+
+	// Every tier routes the default route and every member destination
+	for table := range inspectionRouteTargets {
+		destinations := []string{}
+		for destination := range byTable[table] {
+			destinations = append(destinations, destination)
+		}
+		sort.Strings(destinations)
+		assert.Equal(t, append([]string{anyPrefix.String()}, members...), destinations, "destinations routed from %s", table)
+	}
+}
+
+func TestInspectionReturnRoutes(t *testing.T) {
+	members := memberDestinations(loadModuleRoutes(t))
+
+	cidrs := memberCidrs(t)
+	names := []string{}
+	for name := range cidrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := routedBy(cidrs[name], members); !ok {
+			t.Errorf("%s (%s) has no return route from the inspection VPCs, which route %s", name, cidrs[name], strings.Join(members, ", "))
+		}
+	}
+}
+
+func TestInspectionVPCsAreSymmetric(t *testing.T) {
+	content, err := os.ReadFile("../vpc.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpc := string(content)
+
+	// live_data and non_live_data are the same size, so get the same subnets, from the same module
+	matches := coreNetworkingCidr.FindAllStringSubmatch(vpc, -1)
+	if assert.Len(t, matches, 2, "expected live_data and non_live_data CIDRs in vpc.tf") {
+		live, nonLive := netip.MustParsePrefix(matches[0][1]), netip.MustParsePrefix(matches[1][1])
+		assert.Equal(t, live.Bits(), nonLive.Bits(), "live_data and non_live_data inspection VPCs should be the same size")
+		assert.False(t, live.Overlaps(nonLive), "live_data and non_live_data inspection VPCs overlap")
+	}
+	assert.Regexp(t, `(?s)module "vpc_inspection" \{\s*for_each\s*=\s*local\.networking\b`, vpc)
+
+	// Each routing domain's transit gateway route table sends its default route to its own inspection VPC
+	content, err = os.ReadFile("../transit-gateway.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Regexp(t, `(?s)resource "aws_ec2_transit_gateway_route" "inspection_route" \{\s*for_each\s*=\s*local\.networking\s*destination_cidr_block\s*=\s*"0\.0\.0\.0/0"\s*transit_gateway_attachment_id\s*=\s*module\.vpc_inspection\[each\.key\]`, string(content))
+}
+
+// inspectionRoutes is one inspection VPC in the inspection_route_targets output
+type inspectionRoutes struct {
+	FirewallEndpoints map[string]string `json:"firewall_endpoints"`
+	NatGateways       map[string]string `json:"nat_gateways"`
+	InternetGateway   string            `json:"internet_gateway"`
+	TransitGateway    string            `json:"transit_gateway"`
+	// Routes maps route table name to destination to target ID
+	Routes map[string]map[string]string `json:"routes"`
+}
+
+// expectedRoutes returns the routes a route table should have, by destination
+func (v inspectionRoutes) expectedRoutes(t *testing.T, table string, members []string) map[string]string {
+	match := inspectionRouteTable.FindStringSubmatch(table)
+	if match == nil {
+		t.Errorf("unexpected route table %s", table)
+		return nil
+	}
+	tier, az := match[1], match[2]
+	ids := map[routeTarget]string{
+		firewallEndpoint: v.FirewallEndpoints[az],
+		natGateway:       v.NatGateways[az],
+		transitGateway:   v.TransitGateway,
+		internetGateway:  v.InternetGateway,
+	}
+
+	expected := map[string]string{anyPrefix.String(): ids[inspectionRouteTargets[tier].Default]}
+	for _, destination := range members {
+		expected[destination] = ids[inspectionRouteTargets[tier].Members]
+	}
+	return expected
+}
+
+// checkInspectionRoutes checks the deployed route tables in each inspection VPC
+// route to the expected target in their own AZ, and that both VPCs have the same routes
+func checkInspectionRoutes(t *testing.T, vpcs map[string]inspectionRoutes) {
+	members := memberDestinations(loadModuleRoutes(t))
+
+	for _, domain := range []string{"live_data", "non_live_data"} {
+		vpc, ok := vpcs[domain]
+		if !assert.True(t, ok, "no %s inspection VPC", domain) {
+			continue
+		}
+		assert.Len(t, vpc.FirewallEndpoints, 3, "%s firewall endpoints", domain)
+		assert.Len(t, vpc.NatGateways, 3, "%s NAT gateways", domain)
+		assert.Len(t, vpc.Routes, 9, "%s route tables", domain)
+		for table, routes := range vpc.Routes {
+			assert.Equal(t, vpc.expectedRoutes(t, table, members), routes, "%s %s routes", domain, table)
+		}
+	}
+
+	// Symmetric: the same route tables and destinations in live and non-live
+	shape := func(vpc inspectionRoutes) map[string][]string {
+		destinations := map[string][]string{}
+		for table, routes := range vpc.Routes {
+			for destination := range routes {
+				destinations[table] = append(destinations[table], destination)
+			}
+			sort.Strings(destinations[table])
+		}
+		return destinations
+	}
+	assert.Equal(t, shape(vpcs["live_data"]), shape(vpcs["non_live_data"]), "live_data and non_live_data inspection routes differ")
+}
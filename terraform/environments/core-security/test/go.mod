@@ -5,8 +5,9 @@ go 1.23.0
 toolchain go1.24.1
 
 require (
-	github.com/gruntwork-io/terratest v0.49.0
-	github.com/stretchr/testify v1.10.0
+	github.com/gruntwork-io/terratest v0.49.0 // indirect
+	github.com/stretchr/testify v1.10.0 // indirect
+	modernisation-platform/coretest v0.0.0
 )
 
 require (
@@ -44,3 +45,5 @@ require (
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace modernisation-platform/coretest => ../../../coretest
@@ -0,0 +1,283 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	schedulerFunctionName = "instance-scheduler-lambda-function"
+	// schedulerDefinition is the application definition whose environments
+	// stand in for the member accounts: development and test are scheduled,
+	// preproduction sets instance_scheduler_skip and production is never
+	// scheduled. It isn't named after a real application, so the accounts
+	// can't be mistaken for ones in environments/*.json.
+	schedulerDefinition = "testdata/environments/scheduler-example.json"
+	// schedulingTag is the tag members use to change how an instance is scheduled
+	schedulingTag = "instance-scheduling"
+	// hubAccount is core-shared-services-production, where the function runs,
+	// and the Modernisation Platform account in the stand-in
+	hubAccount = "000000000000"
+)
+
+// schedulingTagValues are the documented values of the scheduling tag, with ""
+// for an instance without it
+var schedulingTagValues = []string{"", "default", "skip-scheduling", "skip-auto-start", "skip-auto-stop"}
+
+// schedulerAccount is a member account the scheduler is given
+type schedulerAccount struct {
+	name string
+	id   string
+	// skipped is whether the account is production or sets instance_scheduler_skip
+	skipped bool
+}
+
+// loadSchedulerAccounts returns an account, with a made up id, for each of the
+// environments in an application definition, named after the file
+func loadSchedulerAccounts(t *testing.T, path string) []schedulerAccount {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	application := strings.TrimSuffix(filepath.Base(path), ".json")
+	var definition struct {
+		Environments []struct {
+			Name                  string   `json:"name"`
+			InstanceSchedulerSkip []string `json:"instance_scheduler_skip"`
+		} `json:"environments"`
+	}
+	require.NoError(t, json.Unmarshal(content, &definition))
+
+	accounts := []schedulerAccount{}
+	for i, environment := range definition.Environments {
+		accounts = append(accounts, schedulerAccount{
+			name:    application + "-" + environment.Name,
+			id:      fmt.Sprintf("1000000000%02d", i+1),
+			skipped: environment.Name == "production" || slices.Contains(environment.InstanceSchedulerSkip, "true"),
+		})
+	}
+	return accounts
+}
+
+// expectedState is the state the scheduler should leave an instance in after
+// action, as described in source/concepts/environments/instance-scheduling.html.md.erb
+func expectedState(account schedulerAccount, tag, action, initial string) string {
+	switch {
+	case account.skipped,
+		tag == "skip-scheduling",
+		tag == "skip-auto-stop" && action == "Stop",
+		tag == "skip-auto-start" && action == "Start":
+		return initial
+	case action == "Stop":
+		return "stopped"
+	default:
+		return "running"
+	}
+}
+
+// seedEnvironmentManagement creates the environment_management secret with
+// the accounts' ids, and the environment_management_arn parameter the
+// function finds it by, in the hub account
+func seedEnvironmentManagement(t *testing.T, s *standIn, accounts []schedulerAccount) {
+	t.Helper()
+	accountIDs := map[string]string{"core-shared-services-production": hubAccount}
+	for _, account := range accounts {
+		accountIDs[account.name] = account.id
+	}
+	secret, err := json.Marshal(map[string]any{
+		"account_ids":                       accountIDs,
+		"modernisation_platform_account_id": hubAccount,
+	})
+	require.NoError(t, err)
+
+	secrets := secretsmanager.NewFromConfig(s.config(t, hubAccount))
+	created, err := secrets.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{
+		Name:         aws.String("environment_management_" + s.run),
+		SecretString: aws.String(string(secret)),
+	})
+	require.NoError(t, err)
+	s.cleanup(t, "deleting the environment_management secret", func(ctx context.Context) error {
+		_, err := secrets.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: created.ARN, ForceDeleteWithoutRecovery: aws.Bool(true)})
+		return err
+	})
+
+	parameters := ssm.NewFromConfig(s.config(t, hubAccount))
+	_, err = parameters.PutParameter(context.Background(), &ssm.PutParameterInput{
+		Name:      aws.String("environment_management_arn"),
+		Type:      ssmtypes.ParameterTypeString,
+		Value:     created.ARN,
+		Overwrite: aws.Bool(true),
+	})
+	require.NoError(t, err)
+	s.cleanup(t, "deleting the environment_management_arn parameter", func(ctx context.Context) error {
+		_, err := parameters.DeleteParameter(ctx, &ssm.DeleteParameterInput{Name: aws.String("environment_management_arn")})
+		return err
+	})
+}
+
+// ensureSchedulerFunction checks the function is deployed to the stand-in. If
+// it isn't, it's created from the image in INSTANCE_SCHEDULER_IMAGE, as
+// instance-scheduler-lambda-function.tf does, or the test is skipped.
+func ensureSchedulerFunction(t *testing.T, s *standIn) *lambda.Client {
+	t.Helper()
+	client := lambda.NewFromConfig(s.config(t, hubAccount))
+	_, err := client.GetFunction(context.Background(), &lambda.GetFunctionInput{FunctionName: aws.String(schedulerFunctionName)})
+	var notFound *lambdatypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		require.NoError(t, err)
+		return client
+	}
+
+	image := os.Getenv("INSTANCE_SCHEDULER_IMAGE")
+	if image == "" {
+		t.Skipf("%s isn't deployed to the stand-in, set INSTANCE_SCHEDULER_IMAGE to the image to deploy", schedulerFunctionName)
+	}
+	role := s.createRole(t, hubAccount, "InstanceSchedulerLambdaFunctionPolicy")
+	_, err = client.CreateFunction(context.Background(), &lambda.CreateFunctionInput{
+		FunctionName: aws.String(schedulerFunctionName),
+		PackageType:  lambdatypes.PackageTypeImage,
+		Code:         &lambdatypes.FunctionCode{ImageUri: aws.String(image)},
+		Role:         aws.String(role),
+		Timeout:      aws.Int32(600),
+	})
+	require.NoError(t, err)
+	s.cleanup(t, "deleting "+schedulerFunctionName, func(ctx context.Context) error {
+		_, err := client.DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(schedulerFunctionName)})
+		return err
+	})
+	waiter := lambda.NewFunctionActiveV2Waiter(client)
+	require.NoError(t, waiter.Wait(context.Background(), &lambda.GetFunctionInput{FunctionName: aws.String(schedulerFunctionName)}, 5*time.Minute))
+	return client
+}
+
+// invokeScheduler runs the function with action, as the EventBridge schedules do
+func invokeScheduler(t *testing.T, client *lambda.Client, action string) {
+	t.Helper()
+	out, err := client.Invoke(context.Background(), &lambda.InvokeInput{
+		FunctionName: aws.String(schedulerFunctionName),
+		Payload:      []byte(fmt.Sprintf(`{"action": %q}`, action)),
+	})
+	require.NoError(t, err)
+	require.Nil(t, out.FunctionError, "%s failed: %s", action, out.Payload)
+
+	var response struct {
+		StatusCode int `json:"statusCode"`
+	}
+	require.NoError(t, json.Unmarshal(out.Payload, &response), "%s returned %s", action, out.Payload)
+	require.Equal(t, 200, response.StatusCode, "%s returned %s", action, out.Payload)
+}
+
+// TestInstanceSchedulerBehaviour runs the scheduler against a local AWS
+// stand-in with an instance for each scheduling tag value in each member
+// account, and checks which are stopped and started:
+//
+//	AWS_ENDPOINT_URL=http://localhost:4566 INSTANCE_SCHEDULER_IMAGE=<image> go test -run TestInstanceSchedulerBehaviour
+//
+// The stand-in holds the accounts' ids, in the environment_management secret,
+// but not the definitions. The function builds its account list itself, in
+// extractNames, from the environments/*.json definitions it reads from the
+// environments repository, as described in instance-scheduling.html.md.erb.
+// The stand-in can't serve it schedulerDefinition in their place, so
+// INSTANCE_SCHEDULER_IMAGE must be a build of the scheduler with
+// testdata/environments as its definitions. The published image schedules
+// the accounts in the real definitions, none of which are created here, so
+// every instance stays as it was and the test fails.
+func TestInstanceSchedulerBehaviour(t *testing.T) {
+	s := newStandIn(t)
+	accounts := loadSchedulerAccounts(t, schedulerDefinition)
+	client := ensureSchedulerFunction(t, s)
+	seedEnvironmentManagement(t, s, accounts)
+	for _, account := range accounts {
+		s.createRole(t, account.id, "InstanceSchedulerAccess")
+	}
+
+	for action, initial := range map[string]string{"Stop": "running", "Start": "stopped"} {
+		t.Run(action, func(t *testing.T) {
+			tagValues := map[string]string{}
+			for _, account := range accounts {
+				tags := []map[string]string{}
+				for _, value := range schedulingTagValues {
+					instance := map[string]string{"Name": fmt.Sprintf("%s-%s", account.name, value)}
+					if value != "" {
+						instance[schedulingTag] = value
+					}
+					tags = append(tags, instance)
+				}
+				ids := s.runInstances(t, account.id, s.image(t, account.id), tags)
+				if initial == "stopped" {
+					s.stopInstances(t, account.id, ids)
+				}
+				for i, id := range ids {
+					tagValues[id] = schedulingTagValues[i]
+				}
+			}
+
+			invokeScheduler(t, client, action)
+
+			for _, account := range accounts {
+				states := s.instanceStates(t, account.id)
+				for id, state := range states {
+					tag, ok := tagValues[id]
+					if !ok {
+						continue
+					}
+					assert.Equal(t, expectedState(account, tag, action, initial), state,
+						"%s instance %s with %s=%q", account.name, id, schedulingTag, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestExpectedState(t *testing.T) {
+	scheduled := schedulerAccount{name: "scheduler-example-development"}
+	skipped := schedulerAccount{name: "scheduler-example-production", skipped: true}
+
+	tests := []struct {
+		account  schedulerAccount
+		tag      string
+		action   string
+		expected string
+	}{
+		{scheduled, "", "Stop", "stopped"},
+		{scheduled, "default", "Start", "running"},
+		{scheduled, "skip-scheduling", "Stop", "running"},
+		{scheduled, "skip-auto-start", "Stop", "stopped"},
+		{scheduled, "skip-auto-start", "Start", "stopped"},
+		{scheduled, "skip-auto-stop", "Stop", "running"},
+		{scheduled, "skip-auto-stop", "Start", "running"},
+		{skipped, "default", "Stop", "running"},
+	}
+	for _, test := range tests {
+		initial := map[string]string{"Stop": "running", "Start": "stopped"}[test.action]
+		assert.Equal(t, test.expected, expectedState(test.account, test.tag, test.action, initial), "%s %q %s", test.account.name, test.tag, test.action)
+	}
+}
+
+func TestLoadSchedulerAccounts(t *testing.T) {
+	accounts := loadSchedulerAccounts(t, schedulerDefinition)
+	skipped := []string{}
+	for _, account := range accounts {
+		if account.skipped {
+			skipped = append(skipped, account.name)
+		}
+	}
+	assert.Len(t, accounts, 4)
+	assert.Equal(t, []string{"scheduler-example-preproduction", "scheduler-example-production"}, skipped)
+}
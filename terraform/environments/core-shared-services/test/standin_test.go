@@ -0,0 +1,220 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// standInRegion is the region the platform and the stand-in run in
+const standInRegion = "eu-west-2"
+
+// standInRunTag is set on every instance the harness creates, with the run's
+// id as its value, so cleanup can find instances even if creating them failed
+// part way
+const standInRunTag = "instance-scheduler-test"
+
+// cleanupTimeout bounds each cleanup step. Cleanup runs with its own context,
+// so it still runs after the test has failed or timed out.
+const cleanupTimeout = 2 * time.Minute
+
+// standIn is a local AWS stand-in, such as LocalStack, reached through the
+// AWS_ENDPOINT_URL endpoint override. Like LocalStack, it acts as the account
+// whose 12 digit id is the access key id, so one endpoint stands in for the
+// hub account and every member account.
+type standIn struct {
+	endpoint string
+	run      string
+}
+
+// newStandIn returns the stand-in at AWS_ENDPOINT_URL, or skips the test if
+// there isn't one, rather than run against real AWS
+func newStandIn(t *testing.T) *standIn {
+	t.Helper()
+	endpoint := os.Getenv("AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		t.Skip("set AWS_ENDPOINT_URL to a local AWS stand-in, e.g. http://localhost:4566 for LocalStack")
+	}
+	return &standIn{endpoint: endpoint, run: fmt.Sprintf("%d", time.Now().UnixNano())}
+}
+
+// config returns the configuration for acting as account in the stand-in
+func (s *standIn) config(t *testing.T, account string) aws.Config {
+	t.Helper()
+	cfg, err := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion(standInRegion),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(account, "test", "")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.BaseEndpoint = aws.String(s.endpoint)
+	return cfg
+}
+
+// cleanup registers f to run when the test and its subtests finish, pass or
+// fail. A failed step is reported without stopping the steps after it.
+func (s *standIn) cleanup(t *testing.T, description string, f func(ctx context.Context) error) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		if err := f(ctx); err != nil {
+			t.Errorf("cleanup: %s: %v", description, err)
+		}
+	})
+}
+
+// createRole creates an IAM role in account that anyone in the stand-in can
+// assume, and deletes it on cleanup. A role left by an earlier run is reused.
+func (s *standIn) createRole(t *testing.T, account, name string) string {
+	t.Helper()
+	client := iam.NewFromConfig(s.config(t, account))
+	arn := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, name)
+	_, err := client.CreateRole(context.Background(), &iam.CreateRoleInput{
+		RoleName:                 aws.String(name),
+		AssumeRolePolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"sts:AssumeRole"}]}`),
+	})
+	var exists *iamtypes.EntityAlreadyExistsException
+	if err != nil && !errors.As(err, &exists) {
+		t.Fatalf("creating role %s: %v", arn, err)
+	}
+	s.cleanup(t, "deleting role "+arn, func(ctx context.Context) error {
+		_, err := client.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: aws.String(name)})
+		return err
+	})
+	return arn
+}
+
+// runInstances starts an instance in account for each set of tags, also
+// tagged with the run, waits for them to run, and terminates them on cleanup.
+// It returns their ids in the same order as tags.
+func (s *standIn) runInstances(t *testing.T, account, image string, tags []map[string]string) []string {
+	t.Helper()
+	client := ec2.NewFromConfig(s.config(t, account))
+	s.cleanup(t, "terminating instances in "+account, func(ctx context.Context) error {
+		return s.terminateRun(ctx, client)
+	})
+
+	ids := []string{}
+	for _, instance := range tags {
+		instanceTags := []ec2types.Tag{{Key: aws.String(standInRunTag), Value: aws.String(s.run)}}
+		for key, value := range instance {
+			instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		out, err := client.RunInstances(context.Background(), &ec2.RunInstancesInput{
+			ImageId:      aws.String(image),
+			InstanceType: ec2types.InstanceTypeT3Micro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			TagSpecifications: []ec2types.TagSpecification{
+				{ResourceType: ec2types.ResourceTypeInstance, Tags: instanceTags},
+			},
+		})
+		if err != nil {
+			t.Fatalf("running an instance in %s: %v", account, err)
+		}
+		ids = append(ids, aws.ToString(out.Instances[0].InstanceId))
+	}
+
+	waiter := ec2.NewInstanceRunningWaiter(client)
+	if err := waiter.Wait(context.Background(), &ec2.DescribeInstancesInput{InstanceIds: ids}, time.Minute); err != nil {
+		t.Fatalf("waiting for %v in %s to run: %v", ids, account, err)
+	}
+	return ids
+}
+
+// stopInstances stops instances in account and waits for them to stop
+func (s *standIn) stopInstances(t *testing.T, account string, ids []string) {
+	t.Helper()
+	client := ec2.NewFromConfig(s.config(t, account))
+	if _, err := client.StopInstances(context.Background(), &ec2.StopInstancesInput{InstanceIds: ids}); err != nil {
+		t.Fatalf("stopping %v in %s: %v", ids, account, err)
+	}
+	waiter := ec2.NewInstanceStoppedWaiter(client)
+	if err := waiter.Wait(context.Background(), &ec2.DescribeInstancesInput{InstanceIds: ids}, time.Minute); err != nil {
+		t.Fatalf("waiting for %v in %s to stop: %v", ids, account, err)
+	}
+}
+
+// image returns an image the stand-in can run instances from
+func (s *standIn) image(t *testing.T, account string) string {
+	t.Helper()
+	out, err := ec2.NewFromConfig(s.config(t, account)).DescribeImages(context.Background(), &ec2.DescribeImagesInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Images) == 0 {
+		t.Fatalf("the stand-in has no images to run instances from")
+	}
+	return aws.ToString(out.Images[0].ImageId)
+}
+
+// instanceStates returns the state of each instance this run created in
+// account, by instance id. Instances part way through stopping or starting
+// are given the state they're moving to.
+func (s *standIn) instanceStates(t *testing.T, account string) map[string]string {
+	t.Helper()
+	instances, err := s.taggedInstances(context.Background(), ec2.NewFromConfig(s.config(t, account)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]string{}
+	for _, instance := range instances {
+		state := instance.State.Name
+		switch state {
+		case ec2types.InstanceStateNamePending:
+			state = ec2types.InstanceStateNameRunning
+		case ec2types.InstanceStateNameStopping:
+			state = ec2types.InstanceStateNameStopped
+		}
+		states[aws.ToString(instance.InstanceId)] = string(state)
+	}
+	return states
+}
+
+// taggedInstances returns the instances with this run's tag that aren't terminated
+func (s *standIn) taggedInstances(ctx context.Context, client *ec2.Client) ([]ec2types.Instance, error) {
+	instances := []ec2types.Instance{}
+	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag:" + standInRunTag), Values: []string{s.run}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range page.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+	}
+	return instances, nil
+}
+
+// terminateRun terminates every instance with this run's tag, found by tag
+// rather than id so that none are missed
+func (s *standIn) terminateRun(ctx context.Context, client *ec2.Client) error {
+	instances, err := s.taggedInstances(ctx, client)
+	if err != nil || len(instances) == 0 {
+		return err
+	}
+	ids := []string{}
+	for _, instance := range instances {
+		ids = append(ids, aws.ToString(instance.InstanceId))
+	}
+	_, err = client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: ids})
+	return err
+}
@@ -0,0 +1,7 @@
+output "subnet_sets" {
+  description = "The subnet sets created in each business unit VPC, by network"
+  value = {
+    for key, vpc in module.vpc :
+    key => sort(keys(vpc.non_tgw_subnet_arns_by_set))
+  }
+}
@@ -0,0 +1,14 @@
+package test
+
+import (
+	"testing"
+
+	"modernisation-platform/coretest"
+)
+
+func TestMemberVPCs(t *testing.T) {
+	outputs := coretest.Load(t, "../")
+
+	workspace := coretest.Workspace(t, "../")
+	coretest.Run(t, outputs, append(coretest.MemberVPCChecks(t, workspace), coretest.Golden(workspace)))
+}
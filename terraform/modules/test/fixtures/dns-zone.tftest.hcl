@@ -0,0 +1,109 @@
+# dns-zone planned for a fixture business unit network, hmpps-development,
+# with the core-network-services and us-east-1 providers mocked too.
+
+mock_provider "aws" {}
+
+mock_provider "aws" {
+  alias = "core-network-services"
+}
+
+mock_provider "aws" {
+  alias = "aws-us-east-1"
+}
+
+variables {
+  dns_zone = "hmpps-development"
+  vpc_id   = "vpc-0123456789abcdef0"
+  accounts = {
+    general = ["hmpps-development-a", "hmpps-development-b"]
+  }
+  environments = {
+    account_ids = {
+      "hmpps-development-a" = "111111111111"
+      "hmpps-development-b" = "222222222222"
+    }
+  }
+  modernisation_platform_account = "123456789012"
+  public_dns_zone                = { id = "Z0PUBLIC" }
+  private_dns_zone               = { id = "Z0PRIVATE" }
+  monitoring_sns_topic           = "arn:aws:sns:eu-west-2:123456789012:route53-monitoring"
+  tags_common = {
+    business-unit = "HMPPS"
+  }
+  tags_prefix = "hmpps-development"
+}
+
+run "zones" {
+  command = plan
+
+  providers = {
+    aws                       = aws
+    aws.core-network-services = aws.core-network-services
+    aws.aws-us-east-1         = aws.aws-us-east-1
+  }
+
+  assert {
+    condition     = aws_route53_zone.public.name == "hmpps-development.modernisation-platform.service.justice.gov.uk" && aws_route53_zone.private.name == "hmpps-development.modernisation-platform.internal"
+    error_message = "Unexpected zone names"
+  }
+
+  assert {
+    condition     = [for vpc in aws_route53_zone.private.vpc : vpc.vpc_id] == ["vpc-0123456789abcdef0"]
+    error_message = "The private zone should be associated with the VPC"
+  }
+
+  assert {
+    condition     = aws_route53_zone.public.tags == tomap({ business-unit = "HMPPS", Name = "hmpps-development-public-zone" }) && aws_route53_zone.private.tags["Name"] == "hmpps-development-internal-zone"
+    error_message = "Unexpected zone tags"
+  }
+}
+
+run "delegation" {
+  command = plan
+
+  providers = {
+    aws                       = aws
+    aws.core-network-services = aws.core-network-services
+    aws.aws-us-east-1         = aws.aws-us-east-1
+  }
+
+  assert {
+    condition     = [aws_route53_record.mod-ns-public.zone_id, aws_route53_record.mod-ns-public.name, aws_route53_record.mod-ns-public.type] == ["Z0PUBLIC", aws_route53_zone.public.name, "NS"]
+    error_message = "The public zone should be delegated from the platform's public zone"
+  }
+
+  assert {
+    condition     = [aws_route53_record.mod-ns-private.zone_id, aws_route53_record.mod-ns-private.name, aws_route53_record.mod-ns-private.type] == ["Z0PRIVATE", aws_route53_zone.private.name, "NS"]
+    error_message = "The private zone should be delegated from the platform's private zone"
+  }
+
+  assert {
+    condition     = aws_route53_record.mod-ns-public.ttl == 30 && aws_route53_record.mod-ns-private.ttl == 30
+    error_message = "Delegation records should have a 30 second TTL"
+  }
+}
+
+run "ddos_protection" {
+  command = plan
+
+  providers = {
+    aws                       = aws
+    aws.core-network-services = aws.core-network-services
+    aws.aws-us-east-1         = aws.aws-us-east-1
+  }
+
+  assert {
+    condition     = aws_shield_protection.public_hosted_zone.name == "hmpps-development-public-hosted-zone"
+    error_message = "Unexpected Shield protection name"
+  }
+
+  assert {
+    condition     = aws_cloudwatch_metric_alarm.ddos_attack_public_hosted_zone.alarm_name == "DDoSDetected-hmpps-development-public-hosted-zone" && aws_cloudwatch_metric_alarm.ddos_attack_public_hosted_zone.threshold == 1
+    error_message = "Unexpected DDoS alarm"
+  }
+
+  assert {
+    condition     = toset(aws_cloudwatch_metric_alarm.ddos_attack_public_hosted_zone.alarm_actions) == toset([var.monitoring_sns_topic])
+    error_message = "The DDoS alarm should notify the monitoring topic"
+  }
+}
@@ -0,0 +1,161 @@
+# firewall-policy planned with fixture rules and sets shaped like
+# core-network-services' firewall-rules. The random id and rule group ARNs are
+# overridden during plan so the names and policy references can be asserted.
+
+mock_provider "aws" {
+  mock_data "aws_region" {
+    defaults = {
+      name = "eu-west-2"
+    }
+  }
+}
+
+mock_provider "random" {}
+
+override_resource {
+  target          = random_id.policy_id
+  override_during = plan
+  values = {
+    id = "x_2A"
+  }
+}
+
+override_resource {
+  target          = aws_networkfirewall_rule_group.stateful
+  override_during = plan
+  values = {
+    arn = "arn:aws:network-firewall:eu-west-2:123456789012:stateful-rulegroup/stateful"
+  }
+}
+
+override_resource {
+  target          = aws_networkfirewall_rule_group.fqdn-stateful
+  override_during = plan
+  values = {
+    arn = "arn:aws:network-firewall:eu-west-2:123456789012:stateful-rulegroup/fqdn"
+  }
+}
+
+variables {
+  fw_kms_arn             = "arn:aws:kms:eu-west-2:123456789012:key/00000000-0000-0000-0000-000000000000"
+  fw_policy_name         = "core-network-services-fw-policy"
+  fw_rulegroup_name      = "core-network-services-fw-rulegroup"
+  fw_fqdn_rulegroup_name = "core-network-services-fw-fqdn-rulegroup"
+  fw_allowed_domains     = [".gov.uk", "github.com"]
+  fw_home_net_ips        = ["10.26.0.0/16", "10.27.0.0/16"]
+  fw_managed_rule_groups = ["ThreatSignaturesBotnetStrictOrder"]
+  # not in key order, as the module numbers rules by key
+  rules = {
+    platforms_development_https = {
+      action           = "PASS"
+      source_ip        = "$PLATFORMS_DEVELOPMENT"
+      destination_ip   = "ANY"
+      destination_port = "443"
+      protocol         = "TCP"
+    }
+    default_block = {
+      action           = "DROP"
+      source_ip        = "ANY"
+      destination_ip   = "ANY"
+      destination_port = "ANY"
+      protocol         = "IP"
+    }
+    hmpps_development_ssh = {
+      action           = "PASS"
+      source_ip        = "10.26.8.0/21"
+      destination_ip   = "10.27.0.0/16"
+      destination_port = "$SSH"
+      protocol         = "TCP"
+    }
+  }
+  ip_sets = {
+    platforms_development = ["10.26.0.0/21"]
+  }
+  port_sets = {
+    ssh = ["22"]
+  }
+  tags = {
+    business-unit = "Platforms"
+  }
+}
+
+run "rules" {
+  command = plan
+
+  assert {
+    condition     = length(one(one(aws_networkfirewall_rule_group.stateful.rule_group).rules_source).stateful_rule) == 3
+    error_message = "Expected a stateful rule per entry in rules"
+  }
+
+  # sids are 1, 2, 3 in key order
+  assert {
+    condition = [for rule in one(one(aws_networkfirewall_rule_group.stateful.rule_group).rules_source).stateful_rule : [one(rule.header).destination_port, one(one(rule.rule_option).settings)]] == [
+      ["ANY", "1"],
+      ["$SSH", "2"],
+      ["443", "3"],
+    ]
+    error_message = "Rules should be numbered by their key order"
+  }
+
+  assert {
+    condition     = alltrue([for rule in one(one(aws_networkfirewall_rule_group.stateful.rule_group).rules_source).stateful_rule : one(rule.header).direction == "ANY" && one(rule.header).source_port == "ANY"])
+    error_message = "Every rule should match either direction from any source port"
+  }
+
+  assert {
+    condition     = sort([for set in one(one(aws_networkfirewall_rule_group.stateful.rule_group).rule_variables).ip_sets : set.key]) == ["PLATFORMS_DEVELOPMENT"] && sort([for set in one(one(aws_networkfirewall_rule_group.stateful.rule_group).rule_variables).port_sets : set.key]) == ["SSH"]
+    error_message = "IP and port set names should be upper case"
+  }
+
+  assert {
+    condition     = aws_networkfirewall_rule_group.stateful.capacity == 10000 && aws_networkfirewall_rule_group.fqdn-stateful.capacity == 3000
+    error_message = "Unexpected rule group capacity"
+  }
+}
+
+run "fqdn_allow_list" {
+  command = plan
+
+  assert {
+    condition     = one(one(one(aws_networkfirewall_rule_group.fqdn-stateful.rule_group).rules_source).rules_source_list).generated_rules_type == "ALLOWLIST"
+    error_message = "The FQDN rule group should be an allow list"
+  }
+
+  assert {
+    condition     = toset(one(one(one(aws_networkfirewall_rule_group.fqdn-stateful.rule_group).rules_source).rules_source_list).targets) == toset(var.fw_allowed_domains)
+    error_message = "The allow list should have every allowed domain"
+  }
+
+  assert {
+    condition     = toset(one(one(one(one(aws_networkfirewall_rule_group.fqdn-stateful.rule_group).rule_variables).ip_sets).ip_set).definition) == toset(var.fw_home_net_ips)
+    error_message = "HOME_NET should be the home network IPs"
+  }
+}
+
+run "policy" {
+  command = plan
+
+  assert {
+    condition     = aws_networkfirewall_firewall_policy.main.name == "corenetworkservicesfwpolicyx2A" && aws_networkfirewall_rule_group.stateful.name == "corenetworkservicesfwrulegroupx2A"
+    error_message = "Names should have the random id appended, with hyphens and underscores removed"
+  }
+
+  assert {
+    condition = toset([for reference in one(aws_networkfirewall_firewall_policy.main.firewall_policy).stateful_rule_group_reference : reference.resource_arn]) == toset([
+      "arn:aws:network-firewall:eu-west-2:aws-managed:stateful-rulegroup/ThreatSignaturesBotnetStrictOrder",
+      "arn:aws:network-firewall:eu-west-2:123456789012:stateful-rulegroup/stateful",
+      "arn:aws:network-firewall:eu-west-2:123456789012:stateful-rulegroup/fqdn",
+    ])
+    error_message = "The policy should reference the managed rule groups, the rule group and the FQDN rule group"
+  }
+
+  assert {
+    condition     = toset(one(aws_networkfirewall_firewall_policy.main.firewall_policy).stateless_default_actions) == toset(["aws:forward_to_sfe"])
+    error_message = "Stateless traffic should be forwarded to the stateful engine"
+  }
+
+  assert {
+    condition     = aws_networkfirewall_firewall_policy.main.tags == var.tags
+    error_message = "The policy should have the given tags"
+  }
+}
@@ -0,0 +1,220 @@
+# vpc-hub planned with fixture inputs shaped like core-logging's live_data VPC.
+# The provider is mocked, so only values known from configuration are asserted.
+
+mock_provider "aws" {
+  mock_data "aws_availability_zones" {
+    defaults = {
+      # out of order, as the module sorts them
+      names = ["eu-west-2c", "eu-west-2a", "eu-west-2b"]
+    }
+  }
+}
+
+variables {
+  vpc_cidr              = "10.20.128.0/19"
+  gateway               = "transit"
+  transit_gateway_id    = "tgw-0123456789abcdef0"
+  vpc_flow_log_iam_role = "arn:aws:iam::123456789012:role/vpc-flow-log"
+  tags_common = {
+    business-unit = "Platforms"
+    application   = "Modernisation Platform: core-logging"
+  }
+  tags_prefix = "live_data"
+}
+
+run "transit_gateway_counts" {
+  command = plan
+
+  assert {
+    condition     = length(aws_subnet.transit-gateway) == 3 && length(aws_subnet.data) == 3 && length(aws_subnet.private) == 3 && length(aws_subnet.public) == 3
+    error_message = "Expected three subnets of each type, one per availability zone"
+  }
+
+  assert {
+    condition     = length(aws_route_table.transit-gateway) == 3 && length(aws_route_table.data) == 3 && length(aws_route_table.private) == 3
+    error_message = "Expected a route table per transit-gateway, data and private subnet"
+  }
+
+  assert {
+    condition     = length(aws_route.private-tgw) == 3 && length(aws_route.data-tgw) == 3 && length(aws_route.transit-gateway-tgw) == 3
+    error_message = "Expected a default route to the transit gateway from each private route table"
+  }
+
+  assert {
+    condition     = alltrue([for route in aws_route.private-tgw : route.transit_gateway_id == var.transit_gateway_id && route.destination_cidr_block == "0.0.0.0/0"])
+    error_message = "Private default routes should go to the transit gateway"
+  }
+
+  assert {
+    condition     = length(aws_nat_gateway.public) == 0 && length(aws_eip.public) == 0 && length(aws_route.private-nat) == 0 && length(aws_route.public_mp_core) == 0
+    error_message = "A transit gateway VPC should have no NAT gateways or NAT routes"
+  }
+
+  assert {
+    condition     = length(aws_flow_log.s3) == 0
+    error_message = "There should be no S3 flow log without a destination"
+  }
+}
+
+run "cidrs_for_a_19" {
+  command = plan
+
+  # the /28s for the transit gateway subnets, then the /23s, data before private before public
+  assert {
+    condition = { for key, subnet in aws_subnet.transit-gateway : key => subnet.cidr_block } == {
+      "transit-gateway-eu-west-2a" = "10.20.128.0/28"
+      "transit-gateway-eu-west-2b" = "10.20.128.16/28"
+      "transit-gateway-eu-west-2c" = "10.20.128.32/28"
+    }
+    error_message = "Unexpected transit gateway subnet CIDRs"
+  }
+
+  assert {
+    condition = { for key, subnet in aws_subnet.data : key => subnet.cidr_block } == {
+      "data-eu-west-2a" = "10.20.130.0/23"
+      "data-eu-west-2b" = "10.20.132.0/23"
+      "data-eu-west-2c" = "10.20.134.0/23"
+    }
+    error_message = "Unexpected data subnet CIDRs"
+  }
+
+  assert {
+    condition = { for key, subnet in aws_subnet.private : key => subnet.cidr_block } == {
+      "private-eu-west-2a" = "10.20.136.0/23"
+      "private-eu-west-2b" = "10.20.138.0/23"
+      "private-eu-west-2c" = "10.20.140.0/23"
+    }
+    error_message = "Unexpected private subnet CIDRs"
+  }
+
+  assert {
+    condition = { for key, subnet in aws_subnet.public : key => subnet.cidr_block } == {
+      "public-eu-west-2a" = "10.20.142.0/23"
+      "public-eu-west-2b" = "10.20.144.0/23"
+      "public-eu-west-2c" = "10.20.146.0/23"
+    }
+    error_message = "Unexpected public subnet CIDRs"
+  }
+
+  assert {
+    condition     = alltrue([for subnet in aws_subnet.private : subnet.availability_zone == trimprefix(subnet.tags["Name"], "live_data-private-")])
+    error_message = "Each subnet should be in the availability zone it's named after"
+  }
+}
+
+run "nacl_rule_numbers" {
+  command = plan
+
+  assert {
+    condition     = length(distinct([for rule in aws_network_acl_rule.private : "${rule.egress}-${rule.rule_number}"])) == length(aws_network_acl_rule.private)
+    error_message = "Private NACL rule numbers should be unique in each direction"
+  }
+
+  assert {
+    condition     = length(distinct([for rule in aws_network_acl_rule.data : "${rule.egress}-${rule.rule_number}"])) == length(aws_network_acl_rule.data)
+    error_message = "Data NACL rule numbers should be unique in each direction"
+  }
+
+  assert {
+    condition     = length(distinct([for rule in aws_network_acl_rule.public : "${rule.egress}-${rule.rule_number}"])) == length(aws_network_acl_rule.public)
+    error_message = "Public NACL rule numbers should be unique in each direction"
+  }
+
+  assert {
+    condition     = alltrue([for rule in concat(values(aws_network_acl_rule.private), values(aws_network_acl_rule.public)) : rule.rule_number >= 1 && rule.rule_number <= 32766])
+    error_message = "NACL rule numbers should be between 1 and 32766"
+  }
+
+  # intra-VPC traffic is allowed first, at 1000, in both directions
+  assert {
+    condition = [for key in ["allow_vpc_cidr_in", "allow_vpc_cidr_out"] : [aws_network_acl_rule.private[key].rule_number, aws_network_acl_rule.private[key].cidr_block]] == [
+      [1000, "10.20.128.0/19"],
+      [1000, "10.20.128.0/19"],
+    ]
+    error_message = "Private NACL should allow the VPC CIDR at rule 1000"
+  }
+
+  assert {
+    condition     = aws_network_acl_rule.public["deny_remote-desktop_tcp_in"].rule_action == "deny" && aws_network_acl_rule.public["deny_remote-desktop_tcp_in"].rule_number < aws_network_acl_rule.public["allow_dynamic_tcp_in"].rule_number
+    error_message = "Public NACL should deny remote desktop before allowing dynamic ports"
+  }
+
+  assert {
+    condition     = length(aws_network_acl_rule.transit-gateway) == 2 && alltrue([for rule in aws_network_acl_rule.transit-gateway : rule.rule_number == 1000 && rule.cidr_block == "0.0.0.0/0"])
+    error_message = "Transit gateway NACL should allow everything at rule 1000"
+  }
+}
+
+run "tags" {
+  command = plan
+
+  assert {
+    condition     = aws_vpc.default.tags == tomap(merge(var.tags_common, { Name = "live_data" }))
+    error_message = "VPC should have the common tags and be named after the prefix"
+  }
+
+  assert {
+    condition     = alltrue([for subnet in concat(values(aws_subnet.transit-gateway), values(aws_subnet.data), values(aws_subnet.private), values(aws_subnet.public)) : subnet.tags["business-unit"] == "Platforms" && startswith(subnet.tags["Name"], "live_data-")])
+    error_message = "Every subnet should have the common tags and a prefixed name"
+  }
+
+  assert {
+    condition     = aws_subnet.data["data-eu-west-2b"].tags["Name"] == "live_data-data-eu-west-2b"
+    error_message = "Subnets should be named <prefix>-<type>-<availability zone>"
+  }
+
+  assert {
+    condition     = aws_network_acl.transit-gateway.tags["Name"] == "live_data-transit-gateway" && aws_internet_gateway.default.tags["Name"] == "live_data-internet-gateway"
+    error_message = "Unexpected NACL or internet gateway name"
+  }
+}
+
+run "nat_gateway_with_a_20" {
+  command = plan
+
+  variables {
+    vpc_cidr                    = "10.26.16.0/20"
+    gateway                     = "nat"
+    tags_prefix                 = "non_live_data"
+    flow_log_s3_destination_arn = "arn:aws:s3:::vpc-flow-logs"
+  }
+
+  # a /20 is split into /28s and /24s rather than /28s and /23s
+  assert {
+    condition = { for key, subnet in aws_subnet.transit-gateway : key => subnet.cidr_block } == {
+      "transit-gateway-eu-west-2a" = "10.26.16.0/28"
+      "transit-gateway-eu-west-2b" = "10.26.16.16/28"
+      "transit-gateway-eu-west-2c" = "10.26.16.32/28"
+    }
+    error_message = "Unexpected transit gateway subnet CIDRs"
+  }
+
+  assert {
+    condition = [for key in ["data-eu-west-2a", "private-eu-west-2a", "public-eu-west-2c"] : merge(aws_subnet.data, aws_subnet.private, aws_subnet.public)[key].cidr_block] == [
+      "10.26.17.0/24",
+      "10.26.20.0/24",
+      "10.26.25.0/24",
+    ]
+    error_message = "Unexpected /24 subnet CIDRs"
+  }
+
+  assert {
+    condition     = length(aws_nat_gateway.public) == 3 && length(aws_eip.public) == 3 && length(aws_route.private-nat) == 3 && length(aws_route.data-nat) == 3
+    error_message = "Expected a NAT gateway and elastic IP per public subnet, and a NAT route per private route table"
+  }
+
+  assert {
+    condition     = length(aws_route.private-tgw) == 0 && length(aws_route.public_mp_core) == 1 && aws_route.public_mp_dev-test[0].destination_cidr_block == "10.26.0.0/16"
+    error_message = "A NAT gateway VPC should route the platform ranges from its public subnets to the transit gateway"
+  }
+
+  assert {
+    condition     = aws_eip.public["public-eu-west-2a"].tags["Name"] == "non_live_data-public-eu-west-2a-nat"
+    error_message = "Unexpected elastic IP name"
+  }
+
+  assert {
+    condition     = length(aws_flow_log.s3) == 1
+    error_message = "Expected an S3 flow log for the destination"
+  }
+}
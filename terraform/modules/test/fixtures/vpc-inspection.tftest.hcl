@@ -0,0 +1,166 @@
+# vpc-inspection planned with fixture inputs shaped like core-network-services'
+# live_data VPC. The providers are mocked, so only values known from
+# configuration are asserted.
+
+mock_provider "aws" {
+  mock_data "aws_availability_zones" {
+    defaults = {
+      names = ["eu-west-2b", "eu-west-2c", "eu-west-2a"]
+    }
+  }
+
+  mock_data "aws_region" {
+    defaults = {
+      name = "eu-west-2"
+    }
+  }
+}
+
+mock_provider "random" {}
+
+variables {
+  application_name      = "core-network-services"
+  vpc_cidr              = "10.20.0.0/19"
+  transit_gateway_id    = "tgw-0123456789abcdef0"
+  vpc_flow_log_iam_role = "arn:aws:iam::123456789012:role/vpc-flow-log"
+  fw_kms_arn            = "arn:aws:kms:eu-west-2:123456789012:key/00000000-0000-0000-0000-000000000000"
+  fw_allowed_domains    = [".gov.uk"]
+  fw_home_net_ips       = ["10.0.0.0/8"]
+  fw_rules = {
+    allow_platform_https = {
+      action           = "PASS"
+      source_ip        = "10.26.0.0/16"
+      destination_ip   = "10.20.0.0/16"
+      destination_port = "443"
+      protocol         = "TCP"
+    }
+  }
+  tags_common = {
+    business-unit     = "Platforms"
+    inline-inspection = "true"
+  }
+  tags_prefix = "live_data"
+}
+
+run "counts" {
+  command = plan
+
+  assert {
+    condition     = length(aws_subnet.transit-gateway) == 3 && length(aws_subnet.inspection) == 3 && length(aws_subnet.public) == 3
+    error_message = "Expected three subnets of each type, one per availability zone"
+  }
+
+  assert {
+    condition     = length(aws_route_table.transit-gateway) == 3 && length(aws_route_table.inspection) == 3 && length(aws_route_table.public) == 3
+    error_message = "Expected a route table per subnet"
+  }
+
+  assert {
+    condition     = length(aws_nat_gateway.public) == 3 && length(aws_eip.public) == 3
+    error_message = "Expected a NAT gateway and elastic IP per public subnet"
+  }
+
+  assert {
+    condition     = length(aws_route.transit-gateway-0-0-0-0) == 3 && length(aws_route.public-10-20-0-0) == 3 && length(aws_route.inspection-10-231-0-0) == 3
+    error_message = "Expected each route in every route table of its type"
+  }
+}
+
+run "cidrs" {
+  command = plan
+
+  # six /28s, transit gateway then inspection, then a /23 per public subnet
+  assert {
+    condition = { for key, subnet in aws_subnet.transit-gateway : key => subnet.cidr_block } == {
+      "transit-gateway-eu-west-2a" = "10.20.0.0/28"
+      "transit-gateway-eu-west-2b" = "10.20.0.16/28"
+      "transit-gateway-eu-west-2c" = "10.20.0.32/28"
+    }
+    error_message = "Unexpected transit gateway subnet CIDRs"
+  }
+
+  assert {
+    condition = { for key, subnet in aws_subnet.inspection : key => subnet.cidr_block } == {
+      "inspection-eu-west-2a" = "10.20.0.48/28"
+      "inspection-eu-west-2b" = "10.20.0.64/28"
+      "inspection-eu-west-2c" = "10.20.0.80/28"
+    }
+    error_message = "Unexpected inspection subnet CIDRs"
+  }
+
+  assert {
+    condition = { for key, subnet in aws_subnet.public : key => subnet.cidr_block } == {
+      "public-eu-west-2a" = "10.20.2.0/23"
+      "public-eu-west-2b" = "10.20.4.0/23"
+      "public-eu-west-2c" = "10.20.6.0/23"
+    }
+    error_message = "Unexpected public subnet CIDRs"
+  }
+
+  # traffic for the platform ranges leaves the transit gateway subnets through the transit gateway
+  assert {
+    condition = distinct([for route in concat(values(aws_route.transit-gateway-10-20-0-0), values(aws_route.transit-gateway-10-26-0-0), values(aws_route.transit-gateway-10-27-0-0), values(aws_route.transit-gateway-10-231-0-0)) : [route.destination_cidr_block, route.transit_gateway_id]]) == [
+      ["10.20.0.0/16", "tgw-0123456789abcdef0"],
+      ["10.26.0.0/16", "tgw-0123456789abcdef0"],
+      ["10.27.0.0/16", "tgw-0123456789abcdef0"],
+      ["10.231.0.0/20", "tgw-0123456789abcdef0"],
+    ]
+    error_message = "Unexpected transit gateway subnet routes"
+  }
+}
+
+run "nacl_rule_numbers" {
+  command = plan
+
+  assert {
+    condition = alltrue([
+      for rules in [aws_network_acl_rule.transit-gateway, aws_network_acl_rule.inspection, aws_network_acl_rule.public] :
+      length(distinct([for rule in rules : "${rule.egress}-${rule.rule_number}"])) == length(rules)
+    ])
+    error_message = "NACL rule numbers should be unique in each direction"
+  }
+
+  assert {
+    condition     = aws_network_acl_rule.inspection["allow_vpc_cidr_in"].rule_number == 1000 && aws_network_acl_rule.inspection["allow_vpc_cidr_in"].cidr_block == var.vpc_cidr
+    error_message = "Inspection NACL should allow the VPC CIDR at rule 1000"
+  }
+
+  assert {
+    condition     = aws_network_acl_rule.public["allow_all_in"].rule_number > aws_network_acl_rule.public["allow_vpc_cidr_in"].rule_number
+    error_message = "Intra-VPC traffic should be allowed before anything else"
+  }
+}
+
+run "attachment_and_tags" {
+  command = plan
+
+  assert {
+    condition     = aws_ec2_transit_gateway_vpc_attachment.attachments-inspection.appliance_mode_support == "enable"
+    error_message = "The inspection attachment needs appliance mode so both directions of a flow use the same firewall endpoint"
+  }
+
+  assert {
+    condition     = !aws_ec2_transit_gateway_vpc_attachment.attachments-inspection.transit_gateway_default_route_table_association && !aws_ec2_transit_gateway_vpc_attachment.attachments-inspection.transit_gateway_default_route_table_propagation
+    error_message = "The inspection attachment should not use the default route table"
+  }
+
+  assert {
+    condition     = aws_ec2_transit_gateway_vpc_attachment.attachments-inspection.tags["Name"] == "core-network-services-live_data-attachment" && aws_ec2_transit_gateway_vpc_attachment.attachments-inspection.tags["inline-inspection"] == "true"
+    error_message = "Unexpected attachment tags"
+  }
+
+  assert {
+    condition     = aws_networkfirewall_firewall.inline_inspection.name == "live-data-inline-inspection" && aws_networkfirewall_firewall.inline_inspection.delete_protection
+    error_message = "The firewall should be named without underscores and be protected from deletion"
+  }
+
+  assert {
+    condition     = aws_vpc.main.tags == tomap(merge(var.tags_common, { Name = "live_data" }))
+    error_message = "VPC should have the common tags and be named after the prefix"
+  }
+
+  assert {
+    condition     = alltrue([for subnet in concat(values(aws_subnet.transit-gateway), values(aws_subnet.inspection), values(aws_subnet.public)) : subnet.tags["inline-inspection"] == "true" && startswith(subnet.tags["Name"], "live_data-") && endswith(subnet.tags["Name"], subnet.availability_zone)])
+    error_message = "Every subnet should have the common tags and be named <prefix>-<type>-<availability zone>"
+  }
+}
@@ -0,0 +1,158 @@
+# vpc-nacls planned against a mocked member VPC, hmpps-development, with a
+# subnet of each type and two additional VPCs and three additional CIDRs.
+
+mock_provider "aws" {}
+
+override_data {
+  target = data.aws_vpc.current
+  values = {
+    id         = "vpc-0current"
+    cidr_block = "10.26.8.0/21"
+  }
+}
+
+override_data {
+  target = data.aws_vpc.external
+  values = {
+    cidr_block = "10.26.24.0/21"
+  }
+}
+
+override_data {
+  target = data.aws_subnets.subnets_all
+  values = {
+    ids = ["subnet-data-a", "subnet-private-a", "subnet-private-b", "subnet-protected-a", "subnet-public-a"]
+  }
+}
+
+override_data {
+  target = data.aws_subnet.subnets_all["subnet-data-a"]
+  values = {
+    tags = { Name = "hmpps-development-general-data-eu-west-2a" }
+  }
+}
+
+override_data {
+  target = data.aws_subnet.subnets_all["subnet-private-a"]
+  values = {
+    tags = { Name = "hmpps-development-general-private-eu-west-2a" }
+  }
+}
+
+override_data {
+  target = data.aws_subnet.subnets_all["subnet-private-b"]
+  values = {
+    tags = { Name = "hmpps-development-general-private-eu-west-2b" }
+  }
+}
+
+override_data {
+  target = data.aws_subnet.subnets_all["subnet-protected-a"]
+  values = {
+    tags = { Name = "hmpps-development-protected-eu-west-2a" }
+  }
+}
+
+override_data {
+  target = data.aws_subnet.subnets_all["subnet-public-a"]
+  values = {
+    tags = { Name = "hmpps-development-general-public-eu-west-2a" }
+  }
+}
+
+variables {
+  vpc_name         = "hmpps-development"
+  tags_prefix      = "hmpps-development"
+  additional_vpcs  = ["hmpps-test", "hmpps-preproduction"]
+  additional_cidrs = ["10.180.0.0/16", "10.181.0.0/16", "10.182.0.0/16"]
+  tags = {
+    business-unit = "HMPPS"
+  }
+}
+
+run "counts_and_subnets" {
+  command = plan
+
+  assert {
+    condition     = length(aws_network_acl_rule.data_subnet_static_rules) == 19 && length(aws_network_acl_rule.private_subnet_static_rules) == 19 && length(aws_network_acl_rule.public_subnet_static_rules) == 19
+    error_message = "Expected the 19 static rules on the data, private and public NACLs"
+  }
+
+  assert {
+    condition     = length(aws_network_acl_rule.public_subnet_internet_access_rules) == 4 && length(aws_network_acl_rule.protected_subnet_vpc_access_rules) == 4
+    error_message = "Expected 4 internet access rules on the public NACL and 4 VPC access rules on the protected NACL"
+  }
+
+  assert {
+    condition     = length(aws_network_acl_rule.data_subnet_dynamic_vpc_ingress_rules) == 2 && length(aws_network_acl_rule.private_subnet_dynamic_vpc_egress_rules) == 2 && length(aws_network_acl_rule.public_subnet_dynamic_range_ingress_rules) == 3
+    error_message = "Expected a dynamic rule per additional VPC and CIDR in each direction"
+  }
+
+  # subnets are given the NACL for the type in their Name tag
+  assert {
+    condition     = aws_network_acl.general-data.subnet_ids == toset(["subnet-data-a"]) && aws_network_acl.general-private.subnet_ids == toset(["subnet-private-a", "subnet-private-b"])
+    error_message = "Data and private subnets should be associated with their NACLs"
+  }
+
+  assert {
+    condition     = aws_network_acl.general-public.subnet_ids == toset(["subnet-public-a"]) && aws_network_acl.protected.subnet_ids == toset(["subnet-protected-a"])
+    error_message = "Public and protected subnets should be associated with their NACLs"
+  }
+}
+
+run "rule_numbers" {
+  command = plan
+
+  assert {
+    condition     = [for key in ["0", "1"] : aws_network_acl_rule.private_subnet_dynamic_vpc_ingress_rules[key].rule_number] == [2000, 2100]
+    error_message = "Additional VPC rules should be numbered from 2000 in steps of 100"
+  }
+
+  assert {
+    condition     = [for key in ["0", "1", "2"] : aws_network_acl_rule.data_subnet_dynamic_range_egress_rules[key].rule_number] == [6000, 6100, 6200]
+    error_message = "Additional CIDR rules should be numbered from 6000 in steps of 100"
+  }
+
+  assert {
+    condition     = [for key in ["0", "1", "2"] : aws_network_acl_rule.data_subnet_dynamic_range_egress_rules[key].cidr_block] == var.additional_cidrs
+    error_message = "Additional CIDR rules should be in the order the CIDRs are given"
+  }
+
+  # inter-VPC rules must come before the east-west deny at 3000 to take effect
+  assert {
+    condition     = alltrue([for rule in values(aws_network_acl_rule.data_subnet_dynamic_vpc_ingress_rules) : rule.rule_number < aws_network_acl_rule.data_subnet_static_rules["deny_mp_cidr_in"].rule_number])
+    error_message = "Additional VPC rules should come before the deny for the rest of the platform"
+  }
+
+  assert {
+    condition = alltrue([
+      for rules in [
+        concat(values(aws_network_acl_rule.data_subnet_static_rules), values(aws_network_acl_rule.data_subnet_dynamic_vpc_ingress_rules), values(aws_network_acl_rule.data_subnet_dynamic_vpc_egress_rules), values(aws_network_acl_rule.data_subnet_dynamic_range_ingress_rules), values(aws_network_acl_rule.data_subnet_dynamic_range_egress_rules)),
+        concat(values(aws_network_acl_rule.private_subnet_static_rules), values(aws_network_acl_rule.private_subnet_dynamic_vpc_ingress_rules), values(aws_network_acl_rule.private_subnet_dynamic_vpc_egress_rules), values(aws_network_acl_rule.private_subnet_dynamic_range_ingress_rules), values(aws_network_acl_rule.private_subnet_dynamic_range_egress_rules)),
+        concat(values(aws_network_acl_rule.public_subnet_static_rules), values(aws_network_acl_rule.public_subnet_internet_access_rules), values(aws_network_acl_rule.public_subnet_dynamic_vpc_ingress_rules), values(aws_network_acl_rule.public_subnet_dynamic_vpc_egress_rules), values(aws_network_acl_rule.public_subnet_dynamic_range_ingress_rules), values(aws_network_acl_rule.public_subnet_dynamic_range_egress_rules)),
+        values(aws_network_acl_rule.protected_subnet_vpc_access_rules),
+      ] :
+      length(distinct([for rule in rules : "${rule.egress}-${rule.rule_number}"])) == length(rules)
+    ])
+    error_message = "Rule numbers should be unique in each direction on each NACL"
+  }
+
+  assert {
+    condition     = aws_network_acl_rule.data_subnet_static_rules["allow_vpc_cidr_in"].cidr_block == "10.26.8.0/21" && aws_network_acl_rule.protected_subnet_vpc_access_rules["allow_https_in"].cidr_block == "10.26.8.0/21"
+    error_message = "Intra-VPC rules should use the VPC's CIDR"
+  }
+}
+
+run "tags" {
+  command = plan
+
+  assert {
+    condition     = aws_network_acl.general-public.tags == tomap({ Name = "hmpps-development-public-nacl", business-unit = "HMPPS" })
+    error_message = "Unexpected public NACL tags"
+  }
+
+  assert {
+    condition     = [for nacl in [aws_network_acl.general-private, aws_network_acl.general-data, aws_network_acl.protected] : nacl.tags["Name"]] == ["hmpps-development-private-nacl", "hmpps-development-data-nacl", "hmpps-development-protected-nacl"]
+    error_message = "NACLs should be named <prefix>-<type>-nacl"
+  }
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	version_checker "github.com/gruntwork-io/terratest/modules/version-checker"
+)
+
+// modules have a fixture each, fixtures/<module>.tftest.hcl, that plans the
+// module with fixture inputs and mocked providers
+var modules = []string{"dns-zone", "firewall-policy", "vpc-hub", "vpc-inspection", "vpc-nacls"}
+
+// providers is the filesystem mirror the aws and random providers are
+// installed from, so that init never goes to the registry
+const providers = "providers"
+
+// TestModules runs each module's fixture with `terraform test`, as a subtest
+// per run block. Every run is plan-only and every provider is mocked and
+// installed from the mirror, so it needs neither AWS credentials nor a network.
+func TestModules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the module tests run terraform")
+	}
+	mirror, err := filepath.Abs(providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !files.IsExistingDir(mirror) {
+		// the terraform-modules-tests workflow creates the mirror, so it's
+		// only missing from a local checkout that hasn't made one yet
+		message := fmt.Sprintf("no provider mirror at %s, create it with: terraform -chdir=../vpc-inspection providers mirror %s", mirror, mirror)
+		if os.Getenv("CI") != "" {
+			t.Fatal(message)
+		}
+		t.Skip(message)
+	}
+
+	// mocked providers need 1.7, override_during in the fixtures needs 1.11
+	version_checker.CheckVersion(t, version_checker.CheckVersionParams{BinaryPath: "terraform", Binary: version_checker.Terraform, WorkingDir: ".", VersionConstraint: ">= 1.11"})
+
+	for _, module := range modules {
+		t.Run(module, func(t *testing.T) {
+			t.Parallel()
+			dir := copyModule(t, module)
+			options := &terraform.Options{TerraformDir: dir, TerraformBinary: "terraform", NoColor: true}
+			terraform.RunTerraformCommand(t, options, "init", "-backend=false", "-input=false", "-plugin-dir="+mirror)
+			output, err := terraform.RunTerraformCommandE(t, options, "test", "-json")
+
+			runs, diagnostics := parseTestOutput(output)
+			// a failed run makes terraform test exit non-zero, any other
+			// failure has to fail the test itself
+			if err != nil && len(diagnostics) == 0 && !anyFailed(runs) {
+				t.Fatalf("terraform test failed with no failed run: %v", err)
+			}
+			for _, diagnostic := range diagnostics {
+				t.Error(diagnostic)
+			}
+			if len(runs) == 0 {
+				t.Fatalf("terraform test ran nothing:\n%s", output)
+			}
+			for _, run := range runs {
+				t.Run(run.name, func(t *testing.T) {
+					switch run.status {
+					case "pass":
+					case "skip":
+						t.Skip("skipped by terraform test")
+					default:
+						t.Errorf("%s:\n%s", run.status, strings.Join(run.diagnostics, "\n"))
+					}
+				})
+			}
+		})
+	}
+}
+
+// copyModule copies the modules, less this directory and its provider mirror,
+// to a temporary directory, so that init and test leave nothing behind, and
+// adds the module's fixture to its tests directory. The copy keeps the modules directory's name so sources such as
+// ../../modules/firewall-policy still resolve.
+func copyModule(t *testing.T, module string) string {
+	t.Helper()
+	source, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := filepath.Join(t.TempDir(), filepath.Base(source))
+	err = files.CopyFolderContentsWithFilter(source, modules, func(path string) bool {
+		return path != tests && !files.PathContainsHiddenFileOrFolder(path) && !files.PathContainsTerraformState(path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := filepath.Join(modules, module, "tests")
+	if err := os.MkdirAll(fixtures, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fixture := module + ".tftest.hcl"
+	if err := files.CopyFile(filepath.Join("fixtures", fixture), filepath.Join(fixtures, fixture)); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(modules, module)
+}
+
+// anyFailed is whether any run finished with a status other than pass or skip
+func anyFailed(runs []*testRun) bool {
+	for _, run := range runs {
+		if run.status != "pass" && run.status != "skip" {
+			return true
+		}
+	}
+	return false
+}
+
+// testRun is the result of a run block
+type testRun struct {
+	name        string
+	status      string
+	diagnostics []string
+}
+
+// parseTestOutput reads `terraform test -json` output into the runs, in the
+// order they ran, and the diagnostics that aren't for a run, such as
+// configuration errors. Lines that aren't JSON are ignored.
+func parseTestOutput(output string) ([]*testRun, []string) {
+	runs := []*testRun{}
+	byName := map[string]*testRun{}
+	diagnostics := []string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var message struct {
+			Type    string `json:"type"`
+			Run     string `json:"@testrun"`
+			TestRun *struct {
+				Run    string `json:"run"`
+				Status string `json:"status"`
+			} `json:"test_run"`
+			Diagnostic *struct {
+				Severity string `json:"severity"`
+				Summary  string `json:"summary"`
+				Detail   string `json:"detail"`
+			} `json:"diagnostic"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &message) != nil {
+			continue
+		}
+
+		name := message.Run
+		if message.TestRun != nil {
+			name = message.TestRun.Run
+		}
+		run := byName[name]
+		if run == nil && name != "" {
+			run = &testRun{name: name}
+			byName[name] = run
+			runs = append(runs, run)
+		}
+
+		switch {
+		case message.Type == "test_run" && message.TestRun != nil && message.TestRun.Status != "":
+			run.status = message.TestRun.Status
+		case message.Type == "diagnostic" && message.Diagnostic != nil && message.Diagnostic.Severity == "error":
+			text := strings.TrimSpace(message.Diagnostic.Summary + ": " + message.Diagnostic.Detail)
+			if run != nil {
+				run.diagnostics = append(run.diagnostics, text)
+			} else {
+				diagnostics = append(diagnostics, text)
+			}
+		}
+	}
+	return runs, diagnostics
+}
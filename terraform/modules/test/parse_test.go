@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTestOutput(t *testing.T) {
+	output := `Initializing the backend...
+{"@level":"info","@message":"Found 1 file and 2 run blocks","type":"test_abstract","test_abstract":{"tests/vpc-hub.tftest.hcl":["counts","cidrs"]}}
+{"@level":"info","@message":"  \"counts\"... pass","@testfile":"tests/vpc-hub.tftest.hcl","@testrun":"counts","type":"test_run","test_run":{"path":"tests/vpc-hub.tftest.hcl","run":"counts","progress":"complete","status":"pass"}}
+{"@level":"info","@message":"  \"cidrs\"... in progress","@testfile":"tests/vpc-hub.tftest.hcl","@testrun":"cidrs","type":"test_run","test_run":{"path":"tests/vpc-hub.tftest.hcl","run":"cidrs","progress":"running"}}
+{"@level":"error","@message":"Error: Test assertion failed","@testfile":"tests/vpc-hub.tftest.hcl","@testrun":"cidrs","type":"diagnostic","diagnostic":{"severity":"error","summary":"Test assertion failed","detail":"Unexpected data subnet CIDRs"}}
+{"@level":"warn","@message":"Warning: Deprecated attribute","@testfile":"tests/vpc-hub.tftest.hcl","@testrun":"cidrs","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute","detail":""}}
+{"@level":"info","@message":"  \"cidrs\"... fail","@testfile":"tests/vpc-hub.tftest.hcl","@testrun":"cidrs","type":"test_run","test_run":{"path":"tests/vpc-hub.tftest.hcl","run":"cidrs","progress":"complete","status":"fail"}}
+{"@level":"error","@message":"Error: Invalid override target","@testfile":"tests/vpc-hub.tftest.hcl","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid override target","detail":"No data source at data.aws_vpc.missing."}}
+`
+	runs, diagnostics := parseTestOutput(output)
+
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, testRun{name: "counts", status: "pass"}, *runs[0])
+		assert.Equal(t, testRun{name: "cidrs", status: "fail", diagnostics: []string{"Test assertion failed: Unexpected data subnet CIDRs"}}, *runs[1])
+	}
+	assert.Equal(t, []string{"Invalid override target: No data source at data.aws_vpc.missing."}, diagnostics)
+	assert.True(t, anyFailed(runs))
+	assert.False(t, anyFailed(runs[:1]))
+}